@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+var chokepointsCommand = &cobra.Command{
+	Use:   "chokepoints SAVEGAME COUNTRY",
+	Short: "shows systems which are critical for defense of the country",
+	Args:  cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		countryId, err := strconv.ParseUint(args[1], 10, 32)
+		exitOnError(err)
+
+		graph := sgmgraph.NewGraph(gs, sgmgraph.Options{})
+		report := graph.FindChokepoints(sgm.CountryId(countryId))
+
+		tbl := table.New("ID", "System", "Starbase", "Role", "Articulation", "Cuts Off", "Hostile")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		for _, cp := range report.Chokepoints {
+			level, role := "", ""
+			if starbase := cp.Star.PrimaryStarbase(); starbase != nil {
+				level = starbase.Level
+				role = starbase.Role().String()
+			}
+
+			articulation := ""
+			if cp.IsArticulation {
+				articulation = "yes"
+			}
+
+			tbl.AddRow(cp.StarId, cp.Star.Name(), level, role, articulation,
+				countryNames(gs, cp.CutNeighborIds), countryNames(gs, cp.HostileNeighborIds))
+		}
+		tbl.Print()
+
+		fmt.Println()
+		for _, cut := range report.Cuts {
+			fmt.Printf("%s: %d hyperlanes\n",
+				sgm.CountryName(cut.NeighborId, gs.Countries[cut.NeighborId]), len(cut.Edges))
+		}
+	},
+}
+
+func countryNames(gs *sgm.GameState, countryIds []sgm.CountryId) string {
+	names := make([]string, 0, len(countryIds))
+	for _, countryId := range countryIds {
+		names = append(names, sgm.CountryName(countryId, gs.Countries[countryId]))
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(chokepointsCommand)
+}
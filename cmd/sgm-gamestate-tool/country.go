@@ -10,6 +10,7 @@ import (
 type countryRow struct {
 	CountryId sgm.CountryId
 	Name      string
+	Player    string
 }
 
 var countriesCommand = &cobra.Command{
@@ -34,14 +35,17 @@ var countriesCommand = &cobra.Command{
 				CountryId: countryId,
 				Name:      country.Name(),
 			}
+			if country.IsHuman() {
+				row.Player = country.Player.Name
+			}
 
 			countries = append(countries, row)
 		}
 
-		tbl := table.New("ID", "Country")
+		tbl := table.New("ID", "Country", "Player")
 		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
 		for _, row := range countries {
-			tbl.AddRow(row.CountryId, row.Name)
+			tbl.AddRow(row.CountryId, row.Name, row.Player)
 		}
 		tbl.Print()
 	},
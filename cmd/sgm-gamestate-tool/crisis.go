@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+var crisisCommand = &cobra.Command{
+	Use:   "crisis SAVEGAME",
+	Short: "shows endgame crisis progress",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		if len(gs.Crises) == 0 {
+			fmt.Println("no crisis in the galaxy")
+			return
+		}
+
+		tbl := table.New("ID", "Country", "Crisis", "Stage (est.)", "Systems", "Fleets", "Power",
+			"Portals", "Hubs")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		for _, crisis := range gs.Crises {
+			tbl.AddRow(crisis.CountryId, crisis.Country.Name(), crisis.Type, crisis.TerritoryStage(),
+				len(crisis.Systems), len(crisis.Fleets), fmt.Sprintf("%.f", crisis.MilitaryPower),
+				crisis.StructureCount(sgm.CrisisStructurePortal),
+				crisis.StructureCount(sgm.CrisisStructureSterilizationHub))
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(crisisCommand)
+}
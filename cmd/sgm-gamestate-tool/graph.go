@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+var graphFormat string
+
+var graphGraphOpts sgmgraph.Options
+
+var graphCommand = &cobra.Command{
+	Use:   "graph SAVEGAME [OUTFILE]",
+	Short: "exports hyperlane graph in GraphML or Graphviz DOT format",
+	Args:  cobra.RangeArgs(1, 2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		format := graphFormat
+		if format == "" {
+			format = "graphml"
+			if len(args) > 1 && filepath.Ext(args[1]) == ".dot" {
+				format = "dot"
+			}
+		}
+		if format != "graphml" && format != "dot" {
+			exitOnError(fmt.Errorf("unknown graph format '%s', should be graphml or dot", format))
+		}
+
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		var w io.Writer = os.Stdout
+		if len(args) > 1 {
+			f, err := os.Create(args[1])
+			exitOnError(err)
+			defer f.Close()
+			w = f
+		}
+
+		graph := sgmgraph.NewGraph(gs, graphGraphOpts)
+		if format == "dot" {
+			err = graph.WriteDOT(w)
+		} else {
+			err = graph.WriteGraphML(w)
+		}
+		exitOnError(err)
+	},
+}
+
+func init() {
+	flags := graphCommand.Flags()
+	flags.StringVar(&graphFormat, "format", "",
+		"output format: graphml or dot, guessed from extension of OUTFILE by default")
+	flags.BoolVar(&graphGraphOpts.UseWormholes, "wormholes", true, "add natural wormholes as edges")
+	flags.BoolVar(&graphGraphOpts.UseGateways, "gateways", true, "add gateways as edges")
+	flags.BoolVar(&graphGraphOpts.UseLGates, "lgates", true, "add L-gates as edges")
+	flags.BoolVar(&graphGraphOpts.UseHyperRelays, "relays", false,
+		"add jumps over chains of hyper relays as edges")
+
+	rootCmd.AddCommand(graphCommand)
+}
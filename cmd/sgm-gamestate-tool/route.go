@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+var routeOpts struct {
+	byDistance  bool
+	useBypasses bool
+	countryId   int
+}
+
+var routeGraphOpts sgmgraph.Options
+
+var routeCommand = &cobra.Command{
+	Use:   "route SAVEGAME FROM TO",
+	Short: "finds the shortest route between two star systems",
+	Args:  cobra.ExactArgs(3),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		_, from, err := gs.FindStar(args[1])
+		exitOnError(err)
+		_, to, err := gs.FindStar(args[2])
+		exitOnError(err)
+
+		graphOpts := routeGraphOpts
+		if routeOpts.useBypasses {
+			graphOpts.UseWormholes = true
+			graphOpts.UseGateways = true
+			graphOpts.UseLGates = true
+			graphOpts.UseHyperRelays = true
+		}
+		graphOpts.CountryId = sgm.CountryId(routeOpts.countryId)
+
+		metric := sgmgraph.MetricJumps
+		if routeOpts.byDistance {
+			metric = sgmgraph.MetricDistance
+		}
+
+		graph := sgmgraph.NewGraph(gs, graphOpts)
+		route := graph.ShortestPath(from, to, metric)
+		if route == nil {
+			fmt.Printf("no route from %s to %s\n", from.Name(), to.Name())
+			return
+		}
+
+		tbl := table.New("Jump", "ID", "System", "Owner", "Via", "Distance")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		tbl.AddRow(0, graph.StarId(from), from.Name(), starOwnerName(gs, from), "", "")
+		for i, edge := range route.Edges {
+			tbl.AddRow(i+1, graph.StarId(edge.To), edge.To.Name(), starOwnerName(gs, edge.To),
+				edge.Type, fmt.Sprintf("%.1f", edge.Distance))
+		}
+		tbl.Print()
+
+		fmt.Printf("\n%d jumps, distance %.1f\n", route.Jumps, route.Distance)
+	},
+}
+
+func starOwnerName(gs *sgm.GameState, star *sgm.Star) string {
+	ownerId := star.Owner()
+	if ownerId == sgm.DefaultCountryId {
+		return ""
+	}
+	return sgm.CountryName(ownerId, gs.Countries[ownerId])
+}
+
+func init() {
+	flags := routeCommand.Flags()
+	flags.BoolVar(&routeOpts.byDistance, "distance", false,
+		"minimize travelled distance instead of number of jumps")
+	flags.BoolVar(&routeOpts.useBypasses, "bypasses", false, "use all kinds of bypasses")
+	flags.BoolVar(&routeGraphOpts.UseWormholes, "wormholes", false, "use natural wormholes")
+	flags.BoolVar(&routeGraphOpts.UseGateways, "gateways", false, "use gateways")
+	flags.BoolVar(&routeGraphOpts.UseLGates, "lgates", false, "use L-gates")
+	flags.BoolVar(&routeGraphOpts.UseHyperRelays, "relays", false, "use hyper relays")
+	flags.IntVar(&routeOpts.countryId, "country", 0,
+		"country which travels, used to avoid closed borders and hostile systems")
+	flags.BoolVar(&routeGraphOpts.AvoidClosedBorders, "avoid-closed", false,
+		"avoid systems with borders closed for the country")
+	flags.BoolVar(&routeGraphOpts.AvoidHostile, "avoid-hostile", false,
+		"avoid systems of countries at war with the country and crisis")
+
+	rootCmd.AddCommand(routeCommand)
+}
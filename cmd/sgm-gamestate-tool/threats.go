@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+var threatOpts sgmgraph.ThreatOptions
+
+var threatsCommand = &cobra.Command{
+	Use:   "threats SAVEGAME COUNTRY",
+	Short: "shows systems of the country exposed to hostile fleets",
+	Args:  cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		countryId, err := strconv.ParseUint(args[1], 10, 32)
+		exitOnError(err)
+
+		graph := sgmgraph.NewGraph(gs, sgmgraph.Options{})
+		threats := graph.ComputeThreats(sgm.CountryId(countryId), threatOpts)
+		if len(threats) == 0 {
+			fmt.Println("no hostile fleets nearby")
+			return
+		}
+
+		tbl := table.New("ID", "System", "Threat", "Fleets", "Strongest Fleet")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		for _, threat := range threats {
+			var strongest *sgm.Fleet
+			for _, fleet := range threat.Fleets {
+				if strongest == nil || fleet.MilitaryPower > strongest.MilitaryPower {
+					strongest = fleet
+				}
+			}
+
+			tbl.AddRow(threat.StarId, threat.Star.Name(), fmt.Sprintf("%.f", threat.Power),
+				len(threat.Fleets), fmt.Sprintf("%s (%s)", strongest.Name(), strongest.MilitaryPowerString()))
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	flags := threatsCommand.Flags()
+	flags.IntVar(&threatOpts.MaxJumps, "jumps", sgmgraph.DefaultThreatJumps,
+		"maximum number of jumps for fleet to be a threat")
+	flags.BoolVar(&threatOpts.WeightByDistance, "weighted", false,
+		"reduce threat of fleets which are farther away")
+
+	rootCmd.AddCommand(threatsCommand)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+var warsCommand = &cobra.Command{
+	Use:   "wars SAVEGAME",
+	Short: "shows ongoing wars and their battles",
+	Args:  cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := sgm.LoadGameState(args[0])
+		exitOnError(err)
+
+		if len(gs.Wars) == 0 {
+			fmt.Println("no wars in the galaxy")
+			return
+		}
+
+		warIds := make([]sgm.WarId, 0, len(gs.Wars))
+		for warId := range gs.Wars {
+			warIds = append(warIds, warId)
+		}
+		sort.Slice(warIds, func(i, j int) bool { return warIds[i] < warIds[j] })
+
+		tbl := table.New("ID", "Started", "Attacker", "Defender", "Goals", "Exhaustion",
+			"Battles", "Won By Attackers", "Attacker Losses", "Defender Losses")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		for _, warId := range warIds {
+			war := gs.Wars[warId]
+			attackerId, defenderId := war.Leaders()
+
+			attackerWins, attackerLosses, defenderLosses := 0, 0, 0
+			for _, battle := range war.Battles {
+				if battle.AttackerVictory {
+					attackerWins++
+				}
+				attackerLosses += battle.AttackerLosses
+				defenderLosses += battle.DefenderLosses
+			}
+
+			tbl.AddRow(warId, war.StartDate,
+				sgm.CountryName(attackerId, gs.Countries[attackerId]),
+				sgm.CountryName(defenderId, gs.Countries[defenderId]),
+				fmt.Sprintf("%s / %s", war.AttackerWarGoal.Name(), war.DefenderWarGoal.Name()),
+				fmt.Sprintf("%.f%% / %.f%%", 100*war.AttackerWarExhaustion, 100*war.DefenderWarExhaustion),
+				len(war.Battles), attackerWins, attackerLosses, defenderLosses)
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(warsCommand)
+}
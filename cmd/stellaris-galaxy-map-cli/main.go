@@ -8,14 +8,39 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgmrender"
 )
 
-var opts sgmrender.RenderOptions
+var (
+	opts sgmrender.RenderOptions
+
+	povCountryId        int
+	chokepointCountryId int
+	threatCountryId     int
+	warId               int
+	routeFrom           string
+	routeTo             string
+	distanceFrom        string
+	overlays            string
+	battleWars          string
+	outFormat           string
+	pngHeight           int
+	pdfOpts             sgmrender.PDFOptions
+	tileOpts            sgmrender.TileOptions
+	exportGeoJSON       bool
+	themeName           string
+	listIcons           bool
+	viewport            string
+	viewportCountries   string
+	viewportStar        string
+)
 
 func getSaveLocation() string {
 	homeDir, err := os.UserHomeDir()
@@ -41,7 +66,24 @@ func getOutFileName(fileName string) (string, error) {
 		return "", fmt.Errorf("unexpected file '%s' as input, it should have .sav extension", fileName)
 	}
 
-	return fileName[:len(fileName)-len(ext)] + ".svg", nil
+	return fileName[:len(fileName)-len(ext)] + "." + outFormat, nil
+}
+
+func newCanvasFactory() (sgmrender.CanvasFactory, error) {
+	switch outFormat {
+	case "svg":
+		return sgmrender.NewSVGCanvas, nil
+	case "png":
+		return sgmrender.NewPNGCanvasFactory(pngHeight), nil
+	case "pdf":
+		if _, _, err := sgmrender.ParsePageSize(pdfOpts.PageSize); err != nil {
+			return nil, err
+		}
+		return sgmrender.NewPDFCanvasFactory(pdfOpts), nil
+	case "tiles":
+		return sgmrender.NewTileCanvasFactory(tileOpts), nil
+	}
+	return nil, fmt.Errorf("unknown output format '%s', should be svg, png, pdf or tiles", outFormat)
 }
 
 func renderFile(fileName, outFileName string) error {
@@ -50,23 +92,283 @@ func renderFile(fileName, outFileName string) error {
 		return err
 	}
 
-	r := sgmrender.NewRenderer(state, opts)
+	renderOpts := opts
+	if routeFrom != "" && routeTo != "" {
+		renderOpts.Route, err = findRoute(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if distanceFrom != "" {
+		renderOpts.DistanceSources = nil
+		for _, name := range strings.Split(distanceFrom, ",") {
+			starId, _, err := state.FindStar(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			renderOpts.DistanceSources = append(renderOpts.DistanceSources, starId)
+		}
+	}
+
+	if viewportStar != "" {
+		renderOpts.ViewportStar, _, err = state.FindStar(viewportStar)
+		if err != nil {
+			return err
+		}
+	} else {
+		renderOpts.ViewportRadius = 0
+	}
+
+	newCanvas, err := newCanvasFactory()
+	if err != nil {
+		return err
+	}
+
+	r := sgmrender.NewCanvasRenderer(state, renderOpts, newCanvas)
+	if exportGeoJSON {
+		if err := writeGeoJSON(r, fileName); err != nil {
+			return err
+		}
+	}
+
 	r.Render()
 	return r.Write(outFileName)
 }
 
+func writeGeoJSON(r *sgmrender.Renderer, fileName string) error {
+	f, err := os.Create(strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".geojson")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.WriteGeoJSON(f)
+}
+
+// printIcons prints icon manifest and where each icon is loaded from
+func printIcons() {
+	for _, name := range sgmrender.IconNames() {
+		source := "missing"
+		if sgmrender.HasEmbeddedIcon(name) {
+			source = "built-in"
+		}
+		if opts.IconDir != "" {
+			if _, err := os.Stat(filepath.Join(opts.IconDir, name+".svg")); err == nil {
+				source = opts.IconDir
+			}
+		}
+		fmt.Printf("%-44s %s\n", name, source)
+	}
+}
+
+// loadTheme picks built-in theme by its name or loads theme from file. Name
+// is a path only if it has theme file extension or directory, so files in
+// the current directory don't shadow built-in themes.
+func loadTheme() error {
+	switch strings.ToLower(filepath.Ext(themeName)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		if !strings.ContainsAny(themeName, "/"+string(os.PathSeparator)) {
+			_, err := sgmrender.BuiltinTheme(themeName)
+			opts.Theme = themeName
+			return err
+		}
+	}
+
+	theme, err := sgmrender.LoadTheme(themeName)
+	opts.CustomTheme = theme
+	return err
+}
+
+func findRoute(state *sgm.GameState) ([]sgm.StarId, error) {
+	_, from, err := state.FindStar(routeFrom)
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := state.FindStar(routeTo)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := sgmgraph.NewGraph(state, sgmgraph.Options{
+		UseWormholes:   true,
+		UseGateways:    true,
+		UseLGates:      true,
+		UseHyperRelays: true,
+	})
+	route := graph.ShortestPath(from, to, sgmgraph.MetricJumps)
+	if route == nil {
+		log.Printf("warn: no route from %s to %s", from.Name(), to.Name())
+		return nil, nil
+	}
+
+	starIds := make([]sgm.StarId, 0, len(route.Stars))
+	for _, star := range route.Stars {
+		starIds = append(starIds, graph.StarId(star))
+	}
+	return starIds, nil
+}
+
 func main() {
 	flag.BoolVar(&opts.ShowSectors, "sectors", false, "show sectors")
+	flag.BoolVar(&opts.ShowPlayerNames, "player-names", false,
+		"show names of players under their empires")
+	flag.BoolVar(&opts.ShowHumans, "humans", false, "outline empires of human players")
+	flag.BoolVar(&opts.ShowBypassLinks, "bypass-links", false,
+		"show where wormholes and gateways lead to")
+	flag.BoolVar(&opts.NoAnimation, "no-animation", false, "disable animations")
+	flag.BoolVar(&opts.Interactive, "interactive", false,
+		"add tooltips, ids and data attributes of stars and countries and layer toggles to svg")
 	flag.BoolVar(&opts.NoGrid, "no-grid", false, "hide grid")
 	flag.BoolVar(&opts.NoInsignificantStars, "no-insignificant-stars", false,
 		"hide insignificant stars")
 	flag.BoolVar(&opts.NoStarSystems, "no-star-systems", false,
 		"hide star systems: planets, starbases")
 	flag.BoolVar(&opts.NoHyperLanes, "no-hyperlanes", false, "hide hyperlanes")
+	flag.IntVar(&povCountryId, "pov", -1,
+		"render only what country with specified id knows about the galaxy")
+	flag.StringVar(&routeFrom, "route-from", "",
+		"highlight the shortest route from the star system with this name or id")
+	flag.StringVar(&routeTo, "route-to", "",
+		"highlight the shortest route to the star system with this name or id")
+	flag.IntVar(&chokepointCountryId, "chokepoints", -1,
+		"mark undefended chokepoints of country with specified id")
+	flag.IntVar(&threatCountryId, "threats", -1,
+		"overlay heatmap of hostile fleets threatening country with specified id")
+	flag.IntVar(&opts.ThreatJumps, "threat-jumps", sgmgraph.DefaultThreatJumps,
+		"maximum number of jumps for fleet to be a threat")
+	flag.BoolVar(&opts.ThreatWeighted, "threat-weighted", false,
+		"reduce threat of fleets which are farther away")
+	flag.StringVar(&overlays, "overlay", "",
+		"comma-separated metric overlays: "+strings.Join(sgmrender.BuiltinOverlayNames(), ", "))
+	flag.BoolVar(&opts.ShowDistances, "distances", false,
+		"shade systems by number of jumps from capitals or systems set by -distance-from")
+	flag.StringVar(&distanceFrom, "distance-from", "",
+		"comma-separated names or ids of systems to count jumps from")
+	flag.IntVar(&opts.DistanceRingStep, "distance-rings", 0,
+		"draw rings every specified number of jumps, 5 if zero")
+	flag.BoolVar(&opts.DistanceBypasses, "distance-bypasses", false,
+		"count jumps through wormholes, gateways, L-gates and hyper relays")
+	flag.IntVar(&warId, "war", -1,
+		"recolor the map into sides of war with specified id and show its fronts")
+	flag.BoolVar(&opts.BattleHistory, "battles", false,
+		"show every battle instead of the most recent one in each system")
+	flag.StringVar(&battleWars, "battle-wars", "",
+		"comma-separated ids of wars to show battles of")
+	flag.IntVar(&opts.BattleFromYear, "battle-from", 0, "show battles since specified year")
+	flag.IntVar(&opts.BattleToYear, "battle-to", 0, "show battles until specified year")
+	flag.StringVar(&viewport, "viewport", "",
+		"crop the map to box MINX,MINY,MAXX,MAXY in galaxy coordinates")
+	flag.StringVar(&viewportCountries, "viewport-countries", "",
+		"crop the map to systems of countries with comma-separated ids")
+	flag.StringVar(&viewportStar, "viewport-star", "",
+		"crop the map around the star system with this name or id")
+	flag.Float64Var(&opts.ViewportRadius, "viewport-radius", 100,
+		"radius of area around -viewport-star")
+	flag.Float64Var(&opts.ViewportPadding, "viewport-padding", 0,
+		"padding around cropped area, 40 by default")
+	flag.Float64Var(&opts.Width, "width", 0, "width of the map in pixels, follows height by default")
+	flag.Float64Var(&opts.Height, "height", 0, "height of the map in pixels, 2160 by default")
+	flag.StringVar(&outFormat, "format", "svg", "output format: svg, png, pdf or tiles (directory of png tiles for web viewer)")
+	flag.IntVar(&pngHeight, "png-height", 0,
+		"height of png images in pixels, -height by default")
+	flag.StringVar(&pdfOpts.PageSize, "page-size", "A4",
+		"pdf page size: A0-A5, Letter, Legal, Tabloid or WIDTHxHEIGHT in millimeters")
+	flag.BoolVar(&pdfOpts.Tile, "tile", false, "split pdf poster across several pages")
+	flag.Float64Var(&pdfOpts.DPI, "dpi", 150,
+		"pixels of 2160px map per inch when pdf poster is split across pages")
+	flag.Float64Var(&pdfOpts.Overlap, "overlap", 10,
+		"overlap of adjacent pages of pdf poster in millimeters")
+	flag.BoolVar(&pdfOpts.CropMarks, "crop-marks", true, "draw crop marks on pdf pages")
+	flag.IntVar(&tileOpts.TileSize, "tile-size", 256, "size of tiles in pixels")
+	flag.IntVar(&tileOpts.StarsZoom, "tile-stars-zoom", -1,
+		"zoom level where tiles show stars, their names and fleets, -1 to choose from map size")
+	flag.IntVar(&tileOpts.SystemsZoom, "tile-systems-zoom", -1,
+		"zoom level where tiles show starbases, planets and other system icons")
+	flag.IntVar(&tileOpts.MaxZoom, "tile-max-zoom", -1, "maximum zoom level of tiles")
+	flag.BoolVar(&exportGeoJSON, "geojson", false,
+		"also export territories, stars and hyperlanes as GeoJSON in galaxy coordinates")
+	flag.StringVar(&themeName, "theme", sgmrender.DefaultThemeName,
+		fmt.Sprintf("built-in theme (%s) or path to .yaml, .yml or .json theme file",
+			strings.Join(sgmrender.BuiltinThemeNames(), ", ")))
+	flag.StringVar(&opts.CountryColors, "country-colors", sgmrender.CountryColorsFlag,
+		"country colors: "+strings.Join(sgmrender.CountryColorModes(), ", "))
+	flag.StringVar(&opts.IconDir, "icon-dir", "",
+		"directory of svg icons which replace or extend built-in icons by name")
+	flag.BoolVar(&listIcons, "list-icons", false,
+		"print names of icons used by the map and exit")
 	flag.BoolVar(&opts.NoFleets, "no-fleets", false, "hide fleets")
+	flag.BoolVar(&opts.NoMonsters, "no-monsters", false,
+		"hide leviathans, guardians, space fauna and marauders")
 	flag.Parse()
 	args := flag.Args()
 
+	if povCountryId >= 0 {
+		opts.FogOfWar = true
+		opts.PointOfView = sgm.CountryId(povCountryId)
+	}
+	if chokepointCountryId >= 0 {
+		opts.ShowChokepoints = true
+		opts.ChokepointCountry = sgm.CountryId(chokepointCountryId)
+	}
+	if distanceFrom != "" {
+		opts.ShowDistances = true
+	}
+	if overlays != "" {
+		opts.Overlays = strings.Split(overlays, ",")
+	}
+	if warId >= 0 {
+		opts.ShowWar = true
+		opts.War = sgm.WarId(warId)
+	}
+	if battleWars != "" {
+		opts.BattleHistory = true
+		for _, idStr := range strings.Split(battleWars, ",") {
+			warId, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 32)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.BattleWars = append(opts.BattleWars, sgm.WarId(warId))
+		}
+	}
+	if viewport != "" {
+		for _, coordStr := range strings.Split(viewport, ",") {
+			coord, err := strconv.ParseFloat(strings.TrimSpace(coordStr), 64)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.Viewport = append(opts.Viewport, coord)
+		}
+		if len(opts.Viewport) != 4 {
+			log.Fatalf("viewport should have 4 coordinates, got %d", len(opts.Viewport))
+		}
+	}
+	if viewportCountries != "" {
+		for _, idStr := range strings.Split(viewportCountries, ",") {
+			countryId, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 32)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.ViewportCountries = append(opts.ViewportCountries, sgm.CountryId(countryId))
+		}
+	}
+	if !sgmrender.IsCountryColorMode(opts.CountryColors) {
+		log.Fatalf("unknown country color mode '%s', should be one of %s", opts.CountryColors,
+			strings.Join(sgmrender.CountryColorModes(), ", "))
+	}
+	if threatCountryId >= 0 {
+		opts.ShowThreats = true
+		opts.ThreatCountry = sgm.CountryId(threatCountryId)
+	}
+	if err := loadTheme(); err != nil {
+		log.Fatal(err)
+	}
+	if listIcons {
+		printIcons()
+		return
+	}
+
 	if len(args) == 0 {
 		runBackground()
 		return
@@ -108,7 +410,7 @@ func runBackground() {
 
 			if event.Op&fsnotify.Write == fsnotify.Write {
 				ext := filepath.Ext(event.Name)
-				if ext == ".svg" {
+				if ext != ".sav" {
 					continue
 				}
 
@@ -11,4 +11,6 @@ require (
 	github.com/rodaine/table v1.0.1
 	github.com/spf13/cobra v1.5.0
 	github.com/stretchr/testify v1.7.1
+	golang.org/x/image v0.0.0-20220413100746-70e8d0d3baa9
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,95 @@
+package sgm
+
+import (
+	"sort"
+)
+
+// BypassNetwork is a set of bypasses which allow travelling between any
+// pair of them: a pair of natural wormholes, all active gateways or L-gates
+type BypassNetwork struct {
+	Type     string
+	Bypasses []*Bypass
+}
+
+func (n *BypassNetwork) Stars() (stars []*Star) {
+	for _, bypass := range n.Bypasses {
+		if bypass.Star != nil {
+			stars = append(stars, bypass.Star)
+		}
+	}
+	return
+}
+
+func (b *Bypass) IsUsable() bool {
+	switch b.Type {
+	case BypassWormhole:
+		return true
+	}
+	return b.Active
+}
+
+// LinkedStars returns stars reachable via active bypasses in the system
+func (s *Star) LinkedStars() (stars []*Star) {
+	for _, bypass := range s.BypassRefs {
+		if bypass.Network == nil || !bypass.IsUsable() {
+			continue
+		}
+		for _, other := range bypass.Network.Bypasses {
+			if other != bypass && other.Star != nil && other.Star != s && other.IsUsable() {
+				stars = append(stars, other.Star)
+			}
+		}
+	}
+	return
+}
+
+func (state *GameState) linkBypassRef(bypassId BypassId, star *Star) {
+	if bypassId == DefaultBypassId {
+		return
+	}
+
+	bypass := state.Bypasses[bypassId]
+	if bypass == nil {
+		return
+	}
+	bypass.Star = star
+	star.BypassRefs = append(star.BypassRefs, bypass)
+}
+
+func (state *GameState) linkBypasses() {
+	bypassIds := make([]BypassId, 0, len(state.Bypasses))
+	for bypassId, bypass := range state.Bypasses {
+		if bypass != nil && bypass.Star != nil {
+			bypassIds = append(bypassIds, bypassId)
+		}
+	}
+	sort.Slice(bypassIds, func(i, j int) bool { return bypassIds[i] < bypassIds[j] })
+
+	typeNetworks := make(map[string]*BypassNetwork)
+	for _, bypassId := range bypassIds {
+		bypass := state.Bypasses[bypassId]
+		if bypass.Network != nil {
+			continue
+		}
+
+		switch bypass.Type {
+		case BypassWormhole:
+			network := &BypassNetwork{Type: bypass.Type, Bypasses: []*Bypass{bypass}}
+			bypass.Network = network
+			if linked := state.Bypasses[bypass.LinkedTo]; linked != nil && linked != bypass {
+				network.Bypasses = append(network.Bypasses, linked)
+				linked.Network = network
+			}
+			state.BypassNetworks = append(state.BypassNetworks, network)
+		case BypassGateway, BypassLGate:
+			network := typeNetworks[bypass.Type]
+			if network == nil {
+				network = &BypassNetwork{Type: bypass.Type}
+				typeNetworks[bypass.Type] = network
+				state.BypassNetworks = append(state.BypassNetworks, network)
+			}
+			network.Bypasses = append(network.Bypasses, bypass)
+			bypass.Network = network
+		}
+	}
+}
@@ -18,6 +18,13 @@ type ColorDef struct {
 	Flag Color `sgm:"flag"`
 	Map  Color `sgm:"map"`
 	Ship Color `sgm:"ship"`
+
+	// Set to "no" for colors which game doesn't use as country borders
+	UseAsBorderColor string `sgm:"use_as_border_color"`
+}
+
+func (c *ColorDef) IsBorderColor() bool {
+	return c.UseAsBorderColor != "no"
 }
 
 func (c *Color) Color() colorcode.RGB {
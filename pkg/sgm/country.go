@@ -64,6 +64,31 @@ const (
 
 	BattleTypeShips  = "ships"
 	BattleTypeArmies = "armies"
+
+	CountryTypeDefault         = "default"
+	CountryTypeFallenEmpire    = "fallen_empire"
+	CountryTypeAwakenedEmpire  = "awakened_fallen_empire"
+	CountryTypeLeviathanPrefix = "guardian_"
+
+	// Leviathans which guard a single system and never leave it
+	CountryTypeGuardianFortress  = "guardian_fortress"
+	CountryTypeGuardianSentinels = "guardian_sentinels"
+	CountryTypeGuardianSphere    = "guardian_sphere"
+
+	CountryTypeAmoeba             = "amoeba"
+	CountryTypeAmoebaBorderless   = "amoeba_borderless"
+	CountryTypeCrystal            = "crystal"
+	CountryTypeCrystalBorderless  = "crystal_borderless"
+	CountryTypeDrone              = "drone"
+	CountryTypeDroneBorderless    = "drone_borderless"
+	CountryTypeCloud              = "cloud"
+	CountryTypeCloudBorderless    = "cloud_borderless"
+	CountryTypeTiyanki            = "tiyanki"
+	CountryTypeTiyankiBorderless  = "tiyanki_borderless"
+	CountryTypeMaraudersDormant   = "dormant_marauders"
+	CountryTypeMaraudersRuined    = "ruined_marauders"
+	CountryTypeMaraudersAwakened  = "awakened_marauders"
+	CountryTypeMaraudersCaravaner = "caravaneer_home"
 )
 
 var MegastructureSize = map[string]int{
@@ -123,6 +148,99 @@ const (
 	WarRoleMax
 )
 
+// CountryClass groups country types by how they behave on the map: regular
+// empires own territory, while other classes are non-playable owners of
+// fleets roaming or guarding systems.
+type CountryClass int
+
+const (
+	CountryClassEmpire CountryClass = iota
+	CountryClassLeviathan
+	CountryClassGuardian
+	CountryClassAmoeba
+	CountryClassCrystal
+	CountryClassDrone
+	CountryClassCloud
+	CountryClassWhale
+	CountryClassMarauder
+
+	CountryClassMax
+)
+
+var countryTypeClasses = map[string]CountryClass{
+	CountryTypeGuardianFortress:   CountryClassGuardian,
+	CountryTypeGuardianSentinels:  CountryClassGuardian,
+	CountryTypeGuardianSphere:     CountryClassGuardian,
+	CountryTypeAmoeba:             CountryClassAmoeba,
+	CountryTypeAmoebaBorderless:   CountryClassAmoeba,
+	CountryTypeCrystal:            CountryClassCrystal,
+	CountryTypeCrystalBorderless:  CountryClassCrystal,
+	CountryTypeDrone:              CountryClassDrone,
+	CountryTypeDroneBorderless:    CountryClassDrone,
+	CountryTypeCloud:              CountryClassCloud,
+	CountryTypeCloudBorderless:    CountryClassCloud,
+	CountryTypeTiyanki:            CountryClassWhale,
+	CountryTypeTiyankiBorderless:  CountryClassWhale,
+	CountryTypeMaraudersDormant:   CountryClassMarauder,
+	CountryTypeMaraudersRuined:    CountryClassMarauder,
+	CountryTypeMaraudersAwakened:  CountryClassMarauder,
+	CountryTypeMaraudersCaravaner: CountryClassMarauder,
+}
+
+func (class CountryClass) String() string {
+	return [...]string{
+		"empire",
+		"leviathan",
+		"guardian",
+		"amoeba",
+		"crystal",
+		"drone",
+		"cloud",
+		"whale",
+		"marauder",
+		"undefined",
+	}[class]
+}
+
+// DangerLevel is a coarse estimate of how hard it is to defeat a fleet
+type DangerLevel int
+
+const (
+	DangerLevelLow DangerLevel = iota
+	DangerLevelModerate
+	DangerLevelHigh
+	DangerLevelExtreme
+
+	DangerLevelMax
+)
+
+var dangerLevelPower = [...]float64{
+	DangerLevelLow:      0.0,
+	DangerLevelModerate: 2000.0,
+	DangerLevelHigh:     15000.0,
+	DangerLevelExtreme:  60000.0,
+}
+
+func ComputeDangerLevel(militaryPower float64) DangerLevel {
+	level := DangerLevelLow
+	for l, power := range dangerLevelPower {
+		if militaryPower >= power {
+			level = DangerLevel(l)
+		}
+	}
+	return level
+}
+
+func (level DangerLevel) String() string {
+	return [...]string{
+		"low",
+		"moderate",
+		"high",
+		"extreme",
+		"undefined",
+	}[level]
+}
+
 type StationId uint32
 type Station struct {
 	FleetId FleetId `sgm:"fleet"`
@@ -215,6 +333,11 @@ type Country struct {
 	NameString string `sgm:"name"`
 	NameStruct Name   `sgm:"name,struct"`
 
+	Type          string  `sgm:"type"`
+	MilitaryPower float64 `sgm:"military_power"`
+
+	AscensionPerks []string `sgm:"ascension_perks"`
+
 	Flag CountryFlag `sgm:"flag"`
 
 	CapitalId PlanetId `sgm:"capital"`
@@ -224,7 +347,14 @@ type Country struct {
 		OwnedFleets []OwnedFleet `sgm:"owned_fleets"`
 	} `sgm:"fleets_manager"`
 
+	RelationsManager struct {
+		Relations []Relation `sgm:"relation"`
+	} `sgm:"relations_manager"`
+	TerraIncognita TerraIncognita `sgm:"terra_incognita"`
+
 	Wars []WarRef
+
+	Player *Player
 }
 
 type CountryFlag struct {
@@ -238,6 +368,27 @@ func (c *Country) Name() string {
 	return c.NameString
 }
 
+func (c *Country) Class() CountryClass {
+	if class, ok := countryTypeClasses[c.Type]; ok {
+		return class
+	}
+	if strings.HasPrefix(c.Type, CountryTypeLeviathanPrefix) {
+		return CountryClassLeviathan
+	}
+	return CountryClassEmpire
+}
+
+// IsMonster returns true for non-playable countries such as leviathans,
+// space fauna and marauders
+func (c *Country) IsMonster() bool {
+	return c.Class() != CountryClassEmpire
+}
+
+// IsHuman returns true if country is controlled by a player
+func (c *Country) IsHuman() bool {
+	return c.Player != nil
+}
+
 func CountryName(countryId CountryId, country *Country) string {
 	if country != nil {
 		return country.Name()
@@ -273,6 +424,7 @@ type Megastructure struct {
 	Type     string   `sgm:"type"`
 	Owner    int      `sgm:"owner"`
 	PlanetId PlanetId `sgm:"planet,id"`
+	BypassId BypassId `sgm:"bypass,id"`
 
 	Star   *Star
 	Planet *Planet
@@ -313,6 +465,7 @@ type Fleet struct {
 	Ships   []*Ship
 
 	Starbase *Starbase
+	Star     *Star
 }
 
 func (f *Fleet) Name() string {
@@ -331,6 +484,14 @@ func (fleet *Fleet) IsTransport() bool {
 	return true
 }
 
+func (fleet *Fleet) IsMonster() bool {
+	return fleet.Owner != nil && fleet.Owner.IsMonster()
+}
+
+func (fleet *Fleet) DangerLevel() DangerLevel {
+	return ComputeDangerLevel(fleet.MilitaryPower)
+}
+
 func (fleet *Fleet) MilitaryPowerString() string {
 	if fleet.MilitaryPower > 5000.0 {
 		kiloPower := math.Floor(fleet.MilitaryPower / 1000.0)
@@ -362,9 +523,89 @@ type War struct {
 	Defenders []WarCountry `sgm:"defenders"`
 	Attackers []WarCountry `sgm:"attackers"`
 
+	AttackerWarGoal WarGoal `sgm:"attacker_war_goal"`
+	DefenderWarGoal WarGoal `sgm:"defender_war_goal"`
+
+	// War exhaustion is in range [0, 1]
+	AttackerWarExhaustion float64 `sgm:"attacker_war_exhaustion"`
+	DefenderWarExhaustion float64 `sgm:"defender_war_exhaustion"`
+
+	AttackerWarScore float64 `sgm:"attacker_war_score"`
+	DefenderWarScore float64 `sgm:"defender_war_score"`
+
 	Battles []Battle `sgm:"battles"`
 }
 
+type WarGoal struct {
+	Type       string `sgm:"type"`
+	CasusBelli string `sgm:"casus_belli"`
+}
+
+// Name returns human-readable name of war goal, i.e. "wg_humiliation"
+// becomes "humiliation"
+func (wg *WarGoal) Name() string {
+	if wg.Type == "" {
+		return "none"
+	}
+	return strings.ReplaceAll(strings.TrimPrefix(wg.Type, "wg_"), "_", " ")
+}
+
+type WarSide int
+
+const (
+	WarSideNone WarSide = iota
+	WarSideAttacker
+	WarSideDefender
+
+	WarSideMax
+)
+
+func (side WarSide) String() string {
+	return [...]string{
+		"uninvolved",
+		"attacker",
+		"defender",
+		"undefined",
+	}[side]
+}
+
+// CountrySide returns side of the war country participates in
+func (w *War) CountrySide(countryId CountryId) WarSide {
+	for _, wc := range w.Attackers {
+		if wc.CountryId == countryId {
+			return WarSideAttacker
+		}
+	}
+	for _, wc := range w.Defenders {
+		if wc.CountryId == countryId {
+			return WarSideDefender
+		}
+	}
+	return WarSideNone
+}
+
+// StarSide returns side of the war which controls the system: its occupier
+// if the system is occupied, otherwise its owner
+func (w *War) StarSide(s *Star) WarSide {
+	controllerId := s.Occupier()
+	if controllerId == DefaultCountryId {
+		controllerId = s.Owner()
+	}
+	return w.CountrySide(controllerId)
+}
+
+// Leaders returns ids of countries which lead attackers and defenders
+func (w *War) Leaders() (attackerId, defenderId CountryId) {
+	attackerId, defenderId = DefaultCountryId, DefaultCountryId
+	if len(w.Attackers) > 0 {
+		attackerId = w.Attackers[0].CountryId
+	}
+	if len(w.Defenders) > 0 {
+		defenderId = w.Defenders[0].CountryId
+	}
+	return
+}
+
 type WarCountry struct {
 	CountryId CountryId `sgm:"country,id"`
 }
@@ -383,6 +624,10 @@ type Battle struct {
 	DefenderLosses int `sgm:"defender_losses"`
 }
 
+func (b *Battle) TotalLosses() int {
+	return b.AttackerLosses + b.DefenderLosses
+}
+
 type WarRef struct {
 	WarId      WarId
 	War        *War
@@ -0,0 +1,265 @@
+package sgm
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	CountryTypePrethoryn        = "swarm"
+	CountryTypeContingency      = "ai_empire"
+	CountryTypeUnbidden         = "extradimensional"
+	CountryTypeUnbiddenAberrant = "extradimensional_2"
+	CountryTypeUnbiddenVehement = "extradimensional_3"
+
+	AscensionPerkBecomeTheCrisis = "ap_become_the_crisis"
+
+	// Number of systems owned by crisis at which it is considered spreading
+	// or overwhelming the galaxy, see Crisis.TerritoryStage()
+	crisisStageSpreadingSystems    = 5
+	crisisStageOverwhelmingSystems = 40
+)
+
+type CrisisType int
+
+const (
+	CrisisNone CrisisType = iota
+	CrisisPrethoryn
+	CrisisContingency
+	CrisisUnbidden
+	CrisisPlayer
+
+	CrisisTypeMax
+)
+
+func (ct CrisisType) String() string {
+	return [...]string{
+		"none",
+		"prethoryn",
+		"contingency",
+		"unbidden",
+		"player",
+		"undefined",
+	}[ct]
+}
+
+var crisisCountryTypes = map[string]CrisisType{
+	CountryTypePrethoryn:        CrisisPrethoryn,
+	CountryTypeContingency:      CrisisContingency,
+	CountryTypeUnbidden:         CrisisUnbidden,
+	CountryTypeUnbiddenAberrant: CrisisUnbidden,
+	CountryTypeUnbiddenVehement: CrisisUnbidden,
+}
+
+func (c *Country) CrisisType() CrisisType {
+	if ct, ok := crisisCountryTypes[c.Type]; ok {
+		return ct
+	}
+	for _, perk := range c.AscensionPerks {
+		if perk == AscensionPerkBecomeTheCrisis {
+			return CrisisPlayer
+		}
+	}
+	return CrisisNone
+}
+
+func (c *Country) IsCrisis() bool {
+	return c.CrisisType() != CrisisNone
+}
+
+type CrisisStructureType int
+
+const (
+	CrisisStructurePortal CrisisStructureType = iota
+	CrisisStructureSterilizationHub
+
+	CrisisStructureMax
+)
+
+func (cst CrisisStructureType) String() string {
+	return [...]string{
+		"portal",
+		"hub",
+		"undefined",
+	}[cst]
+}
+
+// Portals are stations which do not have a special marker in the save, so
+// they are recognized by the keys of their names
+var crisisPortalNames = []string{"Dimensional_Portal", "Dimensional Portal"}
+
+// CrisisStructure returns CrisisStructurePortal if fleet is a portal owned
+// by crisis, CrisisStructureMax otherwise. Sterilization hubs are planets,
+// see Planet.CrisisStructure().
+func (fleet *Fleet) CrisisStructure() CrisisStructureType {
+	if fleet.Owner == nil || !fleet.Owner.IsCrisis() {
+		return CrisisStructureMax
+	}
+
+	name := fleet.NameString
+	if name == "" {
+		name = fleet.NameStruct.Key
+	}
+	for _, pattern := range crisisPortalNames {
+		if strings.Contains(name, pattern) {
+			return CrisisStructurePortal
+		}
+	}
+	return CrisisStructureMax
+}
+
+// CrisisStructure returns CrisisStructureSterilizationHub if planet is
+// owned by the Contingency, CrisisStructureMax otherwise. The Contingency
+// doesn't colonize or invade planets, so it only owns machine worlds of its
+// hubs.
+func (p *Planet) CrisisStructure() CrisisStructureType {
+	if p.Owner != nil && p.Owner.CrisisType() == CrisisContingency {
+		return CrisisStructureSterilizationHub
+	}
+	return CrisisStructureMax
+}
+
+type CrisisStage int
+
+const (
+	CrisisStageDefeated CrisisStage = iota
+	CrisisStageEmerging
+	CrisisStageSpreading
+	CrisisStageOverwhelming
+
+	CrisisStageMax
+)
+
+func (stage CrisisStage) String() string {
+	return [...]string{
+		"defeated",
+		"emerging",
+		"spreading",
+		"overwhelming",
+		"undefined",
+	}[stage]
+}
+
+// CrisisStructure is either a fleet of portal or a planet of hub
+type CrisisStructure struct {
+	Type   CrisisStructureType
+	Fleet  *Fleet
+	Planet *Planet
+}
+
+func (cs CrisisStructure) Name() string {
+	if cs.Planet != nil {
+		return cs.Planet.Name()
+	}
+	return cs.Fleet.Name()
+}
+
+// Crisis summarizes presence of one crisis country in the galaxy
+type Crisis struct {
+	Type      CrisisType
+	CountryId CountryId
+	Country   *Country
+
+	Systems    []*Star
+	Fleets     []*Fleet
+	Structures []CrisisStructure
+
+	MilitaryPower float64
+}
+
+// TerritoryStage estimates progress of the crisis by the number of systems
+// it owns as the save doesn't keep progress of non-player crises
+func (crisis *Crisis) TerritoryStage() CrisisStage {
+	switch {
+	case len(crisis.Systems) >= crisisStageOverwhelmingSystems:
+		return CrisisStageOverwhelming
+	case len(crisis.Systems) >= crisisStageSpreadingSystems:
+		return CrisisStageSpreading
+	case len(crisis.Systems) > 0 || len(crisis.Fleets) > 0 || len(crisis.Structures) > 0:
+		return CrisisStageEmerging
+	}
+	return CrisisStageDefeated
+}
+
+func (crisis *Crisis) StructureCount(cst CrisisStructureType) (count int) {
+	for _, structure := range crisis.Structures {
+		if structure.Type == cst {
+			count++
+		}
+	}
+	return
+}
+
+func (state *GameState) findCrises() (crises []*Crisis) {
+	crisisMap := make(map[CountryId]*Crisis)
+	for countryId, country := range state.Countries {
+		if country == nil || !country.IsCrisis() {
+			continue
+		}
+
+		crisis := &Crisis{
+			Type:      country.CrisisType(),
+			CountryId: countryId,
+			Country:   country,
+		}
+		crisisMap[countryId] = crisis
+		crises = append(crises, crisis)
+	}
+	if len(crises) == 0 {
+		return
+	}
+
+	for _, star := range state.Stars {
+		if crisis := crisisMap[star.Owner()]; crisis != nil {
+			crisis.Systems = append(crisis.Systems, star)
+		}
+	}
+
+	for _, fleet := range state.Fleets {
+		if fleet == nil {
+			continue
+		}
+		crisis := crisisMap[fleet.OwnerId]
+		if crisis == nil {
+			continue
+		}
+
+		if cst := fleet.CrisisStructure(); cst != CrisisStructureMax {
+			crisis.Structures = append(crisis.Structures, CrisisStructure{Type: cst, Fleet: fleet})
+		} else if !fleet.Station && !fleet.Civilian {
+			crisis.Fleets = append(crisis.Fleets, fleet)
+			crisis.MilitaryPower += fleet.MilitaryPower
+		}
+	}
+
+	for _, planet := range state.Planets.Planets {
+		if planet == nil {
+			continue
+		}
+		if crisis := crisisMap[planet.OwnerId]; crisis != nil {
+			if cst := planet.CrisisStructure(); cst != CrisisStructureMax {
+				crisis.Structures = append(crisis.Structures,
+					CrisisStructure{Type: cst, Planet: planet})
+			}
+		}
+	}
+
+	sort.Slice(crises, func(i, j int) bool {
+		return crises[i].CountryId < crises[j].CountryId
+	})
+	return
+}
+
+func (s *Star) CrisisStructures() (structures []CrisisStructure) {
+	for _, fleet := range s.Fleets {
+		if cst := fleet.CrisisStructure(); cst != CrisisStructureMax {
+			structures = append(structures, CrisisStructure{Type: cst, Fleet: fleet})
+		}
+	}
+	for _, planet := range s.Planets {
+		if cst := planet.CrisisStructure(); cst != CrisisStructureMax {
+			structures = append(structures, CrisisStructure{Type: cst, Planet: planet})
+		}
+	}
+	return
+}
@@ -54,11 +54,13 @@ type Star struct {
 
 	WormholeIds []WormholeId `sgm:"natural_wormholes"`
 	Wormholes   []*Wormhole
+	BypassRefs  []*Bypass
 	bypasses    []string
 
 	FleetIds []FleetId `sgm:"fleet_presence"`
 	Fleets   []*Fleet
 	mmFleets []*Fleet
+	mFleets  []*Fleet
 
 	Battles []BattleRef
 }
@@ -260,7 +262,7 @@ func (s *Star) MobileMilitaryFleets() []*Fleet {
 	}
 
 	for _, fleet := range s.Fleets {
-		if !fleet.Civilian && fleet.Mobile && !fleet.IsTransport() {
+		if !fleet.Civilian && fleet.Mobile && !fleet.IsTransport() && !fleet.IsMonster() {
 			s.mmFleets = append(s.mmFleets, fleet)
 		}
 	}
@@ -270,6 +272,34 @@ func (s *Star) MobileMilitaryFleets() []*Fleet {
 	return s.mmFleets
 }
 
+// MonsterFleets returns fleets owned by non-playable countries, including
+// immobile guardians, strongest first
+func (s *Star) MonsterFleets() []*Fleet {
+	if s.mFleets != nil {
+		return s.mFleets
+	}
+
+	for _, fleet := range s.Fleets {
+		if !fleet.Station && !fleet.Civilian && fleet.IsMonster() {
+			s.mFleets = append(s.mFleets, fleet)
+		}
+	}
+	sort.Slice(s.mFleets, func(i, j int) bool {
+		return s.mFleets[i].MilitaryPower > s.mFleets[j].MilitaryPower
+	})
+	return s.mFleets
+}
+
+// IsGuarded returns true if system is blocked by a monster which never leaves it
+func (s *Star) IsGuarded() bool {
+	for _, fleet := range s.MonsterFleets() {
+		if !fleet.Mobile {
+			return true
+		}
+	}
+	return false
+}
+
 type Coordinate struct {
 	X float64 `sgm:"x"`
 	Y float64 `sgm:"y"`
@@ -282,18 +312,24 @@ type Hyperlane struct {
 
 type WormholeId uint32
 type Wormhole struct {
-	Bypass BypassId `sgm:"bypass"`
+	Bypass BypassId `sgm:"bypass,id"`
 }
 
+var DefaultBypassId = BypassId(math.MaxUint32)
+
 type BypassId uint32
 type Bypass struct {
 	Type     string   `sgm:"type"`
-	LinkedTo BypassId `sgm:"linked_to"`
+	Active   bool     `sgm:"active"`
+	LinkedTo BypassId `sgm:"linked_to,id"`
 
 	Owner struct {
 		Type int    `sgm:"type"`
 		Id   uint32 `sgm:"id"`
 	} `sgm:"owner"`
+
+	Star    *Star
+	Network *BypassNetwork
 }
 
 var DefaultPlanetId = PlanetId(math.MaxUint32)
@@ -314,6 +350,7 @@ type Planet struct {
 	OrbitalFleet   *Fleet
 
 	OwnerId CountryId `sgm:"owner,id"`
+	Owner   *Country
 
 	EmployablePops int `sgm:"employable_pops"`
 }
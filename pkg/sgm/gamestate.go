@@ -27,13 +27,22 @@ type PlanetState struct {
 	Planets map[PlanetId]*Planet `sgm:"planet"`
 }
 
+type Player struct {
+	Name      string    `sgm:"name"`
+	CountryId CountryId `sgm:"country,id"`
+	Country   *Country
+}
+
 type GameState struct {
 	Name string `sgm:"name"`
 	Date Date   `sgm:"date"`
 
-	Stars    map[StarId]*Star     `sgm:"galactic_object"`
-	Planets  PlanetState          `sgm:"planets"`
-	Bypasses map[BypassId]*Bypass `sgm:"bypasses"`
+	Players []Player `sgm:"player"`
+
+	Stars     map[StarId]*Star         `sgm:"galactic_object"`
+	Planets   PlanetState              `sgm:"planets"`
+	Bypasses  map[BypassId]*Bypass     `sgm:"bypasses"`
+	Wormholes map[WormholeId]*Wormhole `sgm:"natural_wormholes"`
 
 	Countries      map[CountryId]*Country             `sgm:"country"`
 	Sectors        map[SectorId]*Sector               `sgm:"sectors"`
@@ -43,6 +52,9 @@ type GameState struct {
 	Ships          map[ShipId]*Ship                   `sgm:"ships"`
 	Wars           map[WarId]*War                     `sgm:"war"`
 	Pops           map[PopId]*Pop                     `sgm:"pop"`
+
+	Crises         []*Crisis
+	BypassNetworks []*BypassNetwork
 }
 
 func LoadGameState(path string) (*GameState, error) {
@@ -69,13 +81,27 @@ func LoadGameState(path string) (*GameState, error) {
 		state.linkStarRefs(starId, star)
 	}
 	for _, planet := range state.Planets.Planets {
-		if planet != nil && planet.OrbitalFleetId != DefaultFleetId {
+		if planet == nil {
+			continue
+		}
+		if planet.OrbitalFleetId != DefaultFleetId {
 			planet.OrbitalFleet = state.Fleets[planet.OrbitalFleetId]
 		}
+		planet.Owner = state.Countries[planet.OwnerId]
 	}
 	for countryId, country := range state.Countries {
 		state.linkCountryRefs(countryId, country)
 	}
+	for i := range state.Players {
+		player := &state.Players[i]
+		if country := state.Countries[player.CountryId]; country != nil {
+			player.Country = country
+			country.Player = player
+		} else {
+			log.Printf("warn: country #%d of player %s is not found", player.CountryId, player.Name)
+		}
+	}
+	state.linkBypasses()
 	for _, fleet := range state.Fleets {
 		state.linkFleetRefs(fleet)
 	}
@@ -99,6 +125,7 @@ func LoadGameState(path string) (*GameState, error) {
 	for warId, war := range state.Wars {
 		state.linkWarRefs(warId, war)
 	}
+	state.Crises = state.findCrises()
 
 	return state, nil
 }
@@ -143,14 +170,25 @@ func (state *GameState) linkStarRefs(starId StarId, star *Star) {
 			if megastructure.PlanetId != DefaultPlanetId {
 				megastructure.Planet = state.Planets.Planets[megastructure.PlanetId]
 			}
+			state.linkBypassRef(megastructure.BypassId, star)
 		} else {
 			log.Printf("error: megastructure #%d is not found", megastructureId)
 		}
 	}
 
+	for _, wormholeId := range star.WormholeIds {
+		if wormhole := state.Wormholes[wormholeId]; wormhole != nil {
+			star.Wormholes = append(star.Wormholes, wormhole)
+			state.linkBypassRef(wormhole.Bypass, star)
+		} else {
+			log.Printf("error: wormhole #%d is not found", wormholeId)
+		}
+	}
+
 	for _, fleetId := range star.FleetIds {
 		if fleet := state.Fleets[fleetId]; fleet != nil {
 			star.Fleets = append(star.Fleets, fleet)
+			fleet.Star = star
 		} else {
 			log.Printf("error: fleet #%d is not found", fleetId)
 		}
@@ -226,3 +264,24 @@ func (state *GameState) linkWarRefs(warId WarId, war *War) {
 		}
 	}
 }
+
+// FindStar looks up a star by its id or case-insensitive name. If multiple
+// stars share the name, the one with the lowest id is returned.
+func (state *GameState) FindStar(name string) (StarId, *Star, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if star := state.Stars[StarId(id)]; star != nil {
+			return StarId(id), star, nil
+		}
+	}
+
+	foundId, found := DefaultStarId, (*Star)(nil)
+	for starId, star := range state.Stars {
+		if star != nil && strings.EqualFold(star.Name(), name) && starId < foundId {
+			foundId, found = starId, star
+		}
+	}
+	if found == nil {
+		return DefaultStarId, nil, fmt.Errorf("star system '%s' is not found", name)
+	}
+	return foundId, found, nil
+}
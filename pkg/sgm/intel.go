@@ -0,0 +1,197 @@
+package sgm
+
+import (
+	"log"
+)
+
+const (
+	sensorRangeOwned    = 1
+	sensorRangeUpgraded = 2
+	sensorRangeFleet    = 1
+)
+
+type IntelLevel int
+
+const (
+	IntelNone IntelLevel = iota
+	IntelLow
+	IntelMedium
+	IntelHigh
+	IntelFull
+
+	IntelLevelMax
+)
+
+func (level IntelLevel) String() string {
+	return [...]string{
+		"none",
+		"low",
+		"medium",
+		"high",
+		"full",
+		"undefined",
+	}[level]
+}
+
+type Relation struct {
+	CountryId CountryId `sgm:"country,id"`
+
+	Contact        bool `sgm:"contact"`
+	Communications bool `sgm:"communications"`
+	Embassy        bool `sgm:"embassy"`
+	ClosedBorders  bool `sgm:"closed_borders"`
+}
+
+func (rel *Relation) Intel() IntelLevel {
+	switch {
+	case rel.Embassy:
+		return IntelHigh
+	case rel.Communications:
+		return IntelMedium
+	case rel.Contact:
+		return IntelLow
+	}
+	return IntelNone
+}
+
+// TerraIncognita lists systems which are revealed to the country, i.e. no
+// longer terra incognita for it, despite the name of the block. The list
+// grows as the country explores the galaxy.
+type TerraIncognita struct {
+	Systems []StarId `sgm:"systems"`
+}
+
+func (c *Country) Relation(countryId CountryId) *Relation {
+	for i, rel := range c.RelationsManager.Relations {
+		if rel.CountryId == countryId {
+			return &c.RelationsManager.Relations[i]
+		}
+	}
+	return nil
+}
+
+// Visibility is what a single country knows about the galaxy: which systems
+// it has explored, which systems are covered by its sensors and how much
+// intel it has on other countries. Sensor ranges are not serialized into
+// the save, so they are approximated in jumps.
+type Visibility struct {
+	CountryId CountryId
+	Country   *Country
+
+	explored map[*Star]struct{}
+	sensors  map[*Star]struct{}
+	intel    map[CountryId]IntelLevel
+}
+
+func (state *GameState) ComputeVisibility(countryId CountryId) *Visibility {
+	country := state.Countries[countryId]
+	v := &Visibility{
+		CountryId: countryId,
+		Country:   country,
+
+		sensors: make(map[*Star]struct{}),
+		intel:   map[CountryId]IntelLevel{countryId: IntelFull},
+	}
+	if country == nil {
+		log.Printf("warn: country #%d is not found, nothing is visible", countryId)
+		v.explored = make(map[*Star]struct{})
+		return v
+	}
+
+	if len(country.TerraIncognita.Systems) > 0 {
+		v.explored = make(map[*Star]struct{})
+		for _, starId := range country.TerraIncognita.Systems {
+			if star := state.Stars[starId]; star != nil {
+				v.explored[star] = struct{}{}
+			}
+		}
+	}
+
+	for _, rel := range country.RelationsManager.Relations {
+		v.intel[rel.CountryId] = rel.Intel()
+	}
+
+	hasSentryArray := false
+	for _, star := range state.Stars {
+		if !star.IsOwnedBy(countryId) {
+			continue
+		}
+
+		sensorRange := sensorRangeOwned
+		if star.HasUpgradedStarbase() {
+			sensorRange = sensorRangeUpgraded
+		}
+		v.addSensorRange(star, sensorRange)
+
+		for _, ms := range star.Megastructures {
+			if msType, stage := ms.TypeStage(); msType == MegastructureSentryArray && stage >= 3 {
+				hasSentryArray = true
+			}
+		}
+	}
+	for _, ownedFleet := range country.FleetMgr.OwnedFleets {
+		if fleet := state.Fleets[ownedFleet.FleetId]; fleet != nil && fleet.Star != nil {
+			v.addSensorRange(fleet.Star, sensorRangeFleet)
+		}
+	}
+	if hasSentryArray {
+		for _, star := range state.Stars {
+			v.sensors[star] = struct{}{}
+		}
+	}
+
+	// Everything in sensor range is also explored to the extent map needs
+	if v.explored != nil {
+		for star := range v.sensors {
+			v.explored[star] = struct{}{}
+		}
+	}
+	return v
+}
+
+// addSensorRange adds systems within jumps from the star using breadth-first
+// search, so each system is visited once
+func (v *Visibility) addSensorRange(star *Star, jumps int) {
+	visited := map[*Star]struct{}{star: {}}
+	queue := []*Star{star}
+	for jump := 0; len(queue) > 0; jump++ {
+		var next []*Star
+		for _, star := range queue {
+			v.sensors[star] = struct{}{}
+			if jump == jumps {
+				continue
+			}
+			for _, hyperlane := range star.Hyperlanes {
+				if _, ok := visited[hyperlane.To]; hyperlane.To != nil && !ok {
+					visited[hyperlane.To] = struct{}{}
+					next = append(next, hyperlane.To)
+				}
+			}
+		}
+		queue = next
+	}
+}
+
+// IsExplored returns true if country knows system contents. If save
+// doesn't contain information on explored systems, all systems are
+// considered explored.
+func (v *Visibility) IsExplored(star *Star) bool {
+	if v.explored == nil {
+		return true
+	}
+	_, explored := v.explored[star]
+	return explored
+}
+
+func (v *Visibility) IsInSensorRange(star *Star) bool {
+	_, observed := v.sensors[star]
+	return observed
+}
+
+func (v *Visibility) Intel(countryId CountryId) IntelLevel {
+	return v.intel[countryId]
+}
+
+func (v *Visibility) KnowsCountry(countryId CountryId) bool {
+	return v.Intel(countryId) > IntelNone
+}
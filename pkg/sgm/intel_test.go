@@ -0,0 +1,69 @@
+package sgm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmparser"
+)
+
+// Fragment of country from the save where country has explored its home
+// system 0 and system 2, but not system 1
+const terraIncognitaCountry = `
+terra_incognita={
+	size=3
+	data={ 0 0 255 }
+	systems={ 0 2 }
+}
+fleets_manager={
+	owned_fleets={ { fleet=7 } }
+}
+`
+
+// newIntelTestState creates a chain of stars 0 - 1 - ... - 6 where star 0
+// belongs to country 0 which has a fleet in star 4
+func newIntelTestState(t *testing.T) *GameState {
+	country := &Country{}
+	parser := sgmparser.NewParser(sgmparser.NewTokenizer(bytes.NewBufferString(terraIncognitaCountry)))
+	assert.NoError(t, parser.Parse(country))
+
+	state := &GameState{
+		Stars:     make(map[StarId]*Star),
+		Fleets:    make(map[FleetId]*Fleet),
+		Countries: map[CountryId]*Country{0: country},
+	}
+	for i := 0; i < 7; i++ {
+		state.Stars[StarId(i)] = &Star{}
+	}
+	for i := 0; i < 6; i++ {
+		from, to := state.Stars[StarId(i)], state.Stars[StarId(i+1)]
+		from.Hyperlanes = append(from.Hyperlanes, Hyperlane{ToId: StarId(i + 1), To: to})
+		to.Hyperlanes = append(to.Hyperlanes, Hyperlane{ToId: StarId(i), To: from})
+	}
+	state.Stars[0].Sector = &Sector{Owner: 0}
+	state.Fleets[7] = &Fleet{Star: state.Stars[4]}
+	return state
+}
+
+func TestVisibilityTerraIncognita(t *testing.T) {
+	state := newIntelTestState(t)
+	assert.Equal(t, []StarId{0, 2}, state.Countries[0].TerraIncognita.Systems)
+
+	v := state.ComputeVisibility(0)
+	assert.True(t, v.IsExplored(state.Stars[0]))
+	assert.True(t, v.IsExplored(state.Stars[2]))
+	// Systems in sensor range are explored too
+	assert.True(t, v.IsExplored(state.Stars[1]))
+	assert.False(t, v.IsExplored(state.Stars[6]))
+}
+
+func TestVisibilitySensorRange(t *testing.T) {
+	state := newIntelTestState(t)
+
+	v := state.ComputeVisibility(0)
+	for starId, inRange := range []bool{true, true, false, true, true, true, false} {
+		assert.Equal(t, inRange, v.IsInSensorRange(state.Stars[StarId(starId)]), "star %d", starId)
+	}
+}
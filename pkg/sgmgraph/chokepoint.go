@@ -0,0 +1,343 @@
+package sgmgraph
+
+import (
+	"sort"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// Chokepoint is a system of the country which is worth defending
+type Chokepoint struct {
+	StarId sgm.StarId
+	Star   *sgm.Star
+
+	// Losing the system splits territory of the country
+	IsArticulation bool
+	// Neighbors which are cut off by hyperlanes originating in the system
+	CutNeighborIds []sgm.CountryId
+	// Hostile countries which own adjacent systems
+	HostileNeighborIds []sgm.CountryId
+}
+
+// IsDefended returns true if system has a bastion starbase
+func (cp *Chokepoint) IsDefended() bool {
+	starbase := cp.Star.PrimaryStarbase()
+	return starbase != nil && starbase.Role() == sgm.StarbaseRoleBastion
+}
+
+// NeighborCut is the minimum set of hyperlanes in the country territory
+// which separates it from the neighbor
+type NeighborCut struct {
+	NeighborId sgm.CountryId
+	Edges      []Edge
+}
+
+type ChokepointReport struct {
+	CountryId   sgm.CountryId
+	Cuts        []NeighborCut
+	Chokepoints []*Chokepoint
+}
+
+// FindChokepoints analyzes hyperlane network in the territory of the country.
+// Bypasses are not considered as they can't be closed by starbases.
+func (g *Graph) FindChokepoints(countryId sgm.CountryId) *ChokepointReport {
+	report := &ChokepointReport{CountryId: countryId}
+	chokepoints := make(map[*sgm.Star]*Chokepoint)
+	getChokepoint := func(star *sgm.Star) *Chokepoint {
+		cp := chokepoints[star]
+		if cp == nil {
+			cp = &Chokepoint{StarId: g.StarId(star), Star: star}
+			chokepoints[star] = cp
+		}
+		return cp
+	}
+
+	for _, star := range g.articulationPoints(countryId) {
+		getChokepoint(star).IsArticulation = true
+	}
+
+	for _, neighborId := range g.neighborCountries(countryId) {
+		cut := NeighborCut{NeighborId: neighborId, Edges: g.minEdgeCut(countryId, neighborId)}
+		for _, edge := range cut.Edges {
+			cp := getChokepoint(edge.From)
+			cp.CutNeighborIds = appendCountryId(cp.CutNeighborIds, neighborId)
+		}
+		report.Cuts = append(report.Cuts, cut)
+	}
+
+	for _, star := range g.stars {
+		if !star.IsOwnedBy(countryId) {
+			continue
+		}
+		for _, edge := range g.hyperlaneEdges(star) {
+			if isHostileTo(g.state, countryId, edge.To) {
+				cp := getChokepoint(star)
+				cp.HostileNeighborIds = appendCountryId(cp.HostileNeighborIds, edge.To.Owner())
+			}
+		}
+	}
+
+	for _, cp := range chokepoints {
+		report.Chokepoints = append(report.Chokepoints, cp)
+	}
+	sort.Slice(report.Chokepoints, func(i, j int) bool {
+		return report.Chokepoints[i].StarId < report.Chokepoints[j].StarId
+	})
+	return report
+}
+
+func appendCountryId(countryIds []sgm.CountryId, countryId sgm.CountryId) []sgm.CountryId {
+	for _, id := range countryIds {
+		if id == countryId {
+			return countryIds
+		}
+	}
+	return append(countryIds, countryId)
+}
+
+func (g *Graph) hyperlaneEdges(star *sgm.Star) (edges []Edge) {
+	for _, edge := range g.edges[star] {
+		if edge.Type == EdgeHyperlane {
+			edges = append(edges, edge)
+		}
+	}
+	return
+}
+
+// neighborCountries returns countries which own systems adjacent to the
+// country territory or reachable from it through unclaimed space
+func (g *Graph) neighborCountries(countryId sgm.CountryId) (neighborIds []sgm.CountryId) {
+	visited := make(map[*sgm.Star]struct{})
+	var queue []*sgm.Star
+	for _, star := range g.stars {
+		if star.IsOwnedBy(countryId) {
+			visited[star] = struct{}{}
+			queue = append(queue, star)
+		}
+	}
+
+	for len(queue) > 0 {
+		star := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.hyperlaneEdges(star) {
+			if _, ok := visited[edge.To]; ok {
+				continue
+			}
+			visited[edge.To] = struct{}{}
+
+			ownerId := edge.To.Owner()
+			if ownerId == sgm.DefaultCountryId {
+				queue = append(queue, edge.To)
+			} else {
+				neighborIds = appendCountryId(neighborIds, ownerId)
+			}
+		}
+	}
+	sort.Slice(neighborIds, func(i, j int) bool { return neighborIds[i] < neighborIds[j] })
+	return
+}
+
+// articulationPoints finds systems which split territory of the country
+// if lost using Tarjan's algorithm
+func (g *Graph) articulationPoints(countryId sgm.CountryId) (points []*sgm.Star) {
+	disc := make(map[*sgm.Star]int)
+	low := make(map[*sgm.Star]int)
+	isPoint := make(map[*sgm.Star]bool)
+	time := 0
+
+	var visit func(star, parent *sgm.Star)
+	visit = func(star, parent *sgm.Star) {
+		time++
+		disc[star], low[star] = time, time
+
+		children := 0
+		for _, edge := range g.hyperlaneEdges(star) {
+			to := edge.To
+			if to == parent || !to.IsOwnedBy(countryId) {
+				continue
+			}
+			if _, visited := disc[to]; visited {
+				if disc[to] < low[star] {
+					low[star] = disc[to]
+				}
+				continue
+			}
+
+			children++
+			visit(to, star)
+			if low[to] < low[star] {
+				low[star] = low[to]
+			}
+			if parent != nil && low[to] >= disc[star] {
+				isPoint[star] = true
+			}
+		}
+		if parent == nil && children > 1 {
+			isPoint[star] = true
+		}
+	}
+
+	for _, star := range g.stars {
+		if _, visited := disc[star]; !visited && star.IsOwnedBy(countryId) {
+			visit(star, nil)
+		}
+	}
+	for _, star := range g.stars {
+		if isPoint[star] {
+			points = append(points, star)
+		}
+	}
+	return
+}
+
+type flowArc struct {
+	from, to *sgm.Star
+}
+
+// minEdgeCut finds minimum set of hyperlanes separating inner systems of
+// the country from the neighbor using Edmonds-Karp algorithm with unit
+// capacities. Only systems of the country are considered, so all returned
+// edges start in them: cut either closes hyperlanes between systems of the
+// country or exits from its frontier systems towards the neighbor.
+func (g *Graph) minEdgeCut(countryId, neighborId sgm.CountryId) []Edge {
+	isOwned := func(star *sgm.Star) bool {
+		return star.IsOwnedBy(countryId)
+	}
+
+	// Exits lead to the neighbor directly or through unclaimed space
+	towardsNeighbor := g.reachableThroughUnclaimed(neighborId)
+	isExit := func(edge Edge) bool {
+		_, ok := towardsNeighbor[edge.To]
+		return ok && !isOwned(edge.To)
+	}
+
+	// Sources are inner systems of the country which have no exits. If all
+	// systems are on the frontier, the cut consists of the exits.
+	var owned, sources []*sgm.Star
+	for _, star := range g.stars {
+		if !isOwned(star) {
+			continue
+		}
+		owned = append(owned, star)
+
+		hasExit := false
+		for _, edge := range g.hyperlaneEdges(star) {
+			hasExit = hasExit || isExit(edge)
+		}
+		if !hasExit {
+			sources = append(sources, star)
+		}
+	}
+	if len(sources) == 0 {
+		sources = owned
+	}
+
+	flow := make(map[flowArc]int)
+	residual := func(from, to *sgm.Star) int {
+		return 1 - flow[flowArc{from, to}]
+	}
+
+	// Find augmenting paths from sources to exits until there are no more
+	for {
+		reached := make(map[*sgm.Star]Edge)
+		for _, source := range sources {
+			reached[source] = Edge{}
+		}
+		queue := append([]*sgm.Star(nil), sources...)
+
+		var exit *Edge
+		for len(queue) > 0 && exit == nil {
+			star := queue[0]
+			queue = queue[1:]
+			for _, edge := range g.hyperlaneEdges(star) {
+				if residual(star, edge.To) <= 0 {
+					continue
+				}
+				if isExit(edge) {
+					exit = &edge
+					break
+				}
+				if _, ok := reached[edge.To]; ok || !isOwned(edge.To) {
+					continue
+				}
+				reached[edge.To] = edge
+				queue = append(queue, edge.To)
+			}
+		}
+		if exit == nil {
+			break
+		}
+
+		flow[flowArc{exit.From, exit.To}]++
+		for star := exit.From; reached[star].From != nil; {
+			edge := reached[star]
+			flow[flowArc{edge.From, edge.To}]++
+			flow[flowArc{edge.To, edge.From}]--
+			star = edge.From
+		}
+	}
+
+	// Systems which can still reach exits are the sink side of the cut
+	// closest to the neighbor
+	sinkSide := make(map[*sgm.Star]struct{})
+	var queue []*sgm.Star
+	for _, star := range owned {
+		for _, edge := range g.hyperlaneEdges(star) {
+			if isExit(edge) && residual(star, edge.To) > 0 {
+				sinkSide[star] = struct{}{}
+				queue = append(queue, star)
+				break
+			}
+		}
+	}
+	for len(queue) > 0 {
+		star := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.hyperlaneEdges(star) {
+			if _, ok := sinkSide[edge.To]; ok || !isOwned(edge.To) || residual(edge.To, star) <= 0 {
+				continue
+			}
+			sinkSide[edge.To] = struct{}{}
+			queue = append(queue, edge.To)
+		}
+	}
+
+	var cut []Edge
+	for _, star := range owned {
+		if _, ok := sinkSide[star]; ok {
+			continue
+		}
+		for _, edge := range g.hyperlaneEdges(star) {
+			if _, ok := sinkSide[edge.To]; ok || isExit(edge) {
+				cut = append(cut, edge)
+			}
+		}
+	}
+	return cut
+}
+
+// reachableThroughUnclaimed returns systems of the country and unclaimed
+// systems from which they can be reached without crossing other countries
+func (g *Graph) reachableThroughUnclaimed(countryId sgm.CountryId) map[*sgm.Star]struct{} {
+	reached := make(map[*sgm.Star]struct{})
+	var queue []*sgm.Star
+	for _, star := range g.stars {
+		if star.IsOwnedBy(countryId) {
+			reached[star] = struct{}{}
+			queue = append(queue, star)
+		}
+	}
+
+	for len(queue) > 0 {
+		star := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.hyperlaneEdges(star) {
+			if _, ok := reached[edge.To]; ok || edge.To.Owner() != sgm.DefaultCountryId {
+				continue
+			}
+			reached[edge.To] = struct{}{}
+			queue = append(queue, edge.To)
+		}
+	}
+	return reached
+}
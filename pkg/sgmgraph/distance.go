@@ -0,0 +1,42 @@
+package sgmgraph
+
+import (
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// JumpField returns number of jumps from the nearest source to every
+// reachable system. If maxJumps is positive, farther systems are omitted.
+func (g *Graph) JumpField(sources []*sgm.Star, maxJumps int) map[*sgm.Star]int {
+	jumps := make(map[*sgm.Star]int)
+	for _, source := range sources {
+		jumps[source] = 0
+	}
+
+	front := sources
+	for jump := 1; (maxJumps <= 0 || jump <= maxJumps) && len(front) > 0; jump++ {
+		var nextFront []*sgm.Star
+		for _, from := range front {
+			if jump > 1 && g.IsBlocked(from) {
+				continue
+			}
+			for _, edge := range g.edges[from] {
+				if _, visited := jumps[edge.To]; !visited {
+					jumps[edge.To] = jump
+					nextFront = append(nextFront, edge.To)
+				}
+			}
+		}
+		front = nextFront
+	}
+	return jumps
+}
+
+// Capitals returns systems with capitals of all countries
+func (g *Graph) Capitals() (stars []*sgm.Star) {
+	for _, star := range g.stars {
+		if star.HasCapital() {
+			stars = append(stars, star)
+		}
+	}
+	return
+}
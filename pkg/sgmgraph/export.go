@@ -0,0 +1,178 @@
+package sgmgraph
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+type exportAttr struct {
+	name     string
+	attrType string
+}
+
+var exportNodeAttrs = []exportAttr{
+	{"name", "string"},
+	{"x", "double"},
+	{"y", "double"},
+	{"owner_id", "long"},
+	{"owner", "string"},
+	{"starbase", "string"},
+	{"significant", "boolean"},
+	{"capital", "boolean"},
+	{"pops", "boolean"},
+	{"distant", "boolean"},
+	{"bypasses", "string"},
+}
+
+var exportEdgeAttrs = []exportAttr{
+	{"type", "string"},
+	{"distance", "double"},
+}
+
+// exportNode returns attribute values of the star in order of exportNodeAttrs,
+// coordinates are galaxy coordinates from the save game
+func (g *Graph) exportNode(star *sgm.Star) []string {
+	ownerId, owner := "", ""
+	if countryId := star.Owner(); countryId != sgm.DefaultCountryId {
+		ownerId = strconv.FormatUint(uint64(countryId), 10)
+		owner = sgm.CountryName(countryId, g.state.Countries[countryId])
+	}
+	starbase := ""
+	if sb := star.PrimaryStarbase(); sb != nil {
+		starbase = strings.TrimPrefix(sb.Level, "starbase_level_")
+	}
+
+	return []string{
+		star.Name(),
+		strconv.FormatFloat(star.Coordinate.X, 'f', -1, 64),
+		strconv.FormatFloat(star.Coordinate.Y, 'f', -1, 64),
+		ownerId,
+		owner,
+		starbase,
+		strconv.FormatBool(star.IsSignificant()),
+		strconv.FormatBool(star.HasCapital()),
+		strconv.FormatBool(star.HasPops()),
+		strconv.FormatBool(star.IsDistant()),
+		strings.Join(star.Bypasses(), ","),
+	}
+}
+
+// undirectedEdges returns each edge of the graph once, so edges of the
+// star with the smaller id are used
+func (g *Graph) undirectedEdges() (edges []Edge) {
+	type edgeKey struct {
+		from, to sgm.StarId
+		edgeType EdgeType
+	}
+	seen := make(map[edgeKey]struct{})
+
+	for _, star := range g.stars {
+		for _, edge := range g.edges[star] {
+			from, to := g.StarId(edge.From), g.StarId(edge.To)
+			if from > to {
+				from, to = to, from
+			}
+			key := edgeKey{from: from, to: to, edgeType: edge.Type}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			edges = append(edges, edge)
+		}
+	}
+	return
+}
+
+func (g *Graph) exportEdge(edge Edge) []string {
+	return []string{
+		edge.Type.String(),
+		strconv.FormatFloat(edge.Distance, 'f', -1, 64),
+	}
+}
+
+// WriteGraphML writes the graph in GraphML format, stars are nodes with
+// their ids and edges are undirected
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	escape := func(s string) string {
+		var sb strings.Builder
+		xml.EscapeText(&sb, []byte(s))
+		return sb.String()
+	}
+
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, attr := range exportNodeAttrs {
+		fmt.Fprintf(bw, `  <key id="%s" for="node" attr.name="%s" attr.type="%s"/>`+"\n",
+			attr.name, attr.name, attr.attrType)
+	}
+	for _, attr := range exportEdgeAttrs {
+		fmt.Fprintf(bw, `  <key id="%s" for="edge" attr.name="%s" attr.type="%s"/>`+"\n",
+			attr.name, attr.name, attr.attrType)
+	}
+	bw.WriteString(`  <graph id="galaxy" edgedefault="undirected">` + "\n")
+
+	writeData := func(attrs []exportAttr, values []string) {
+		for i, value := range values {
+			if value != "" {
+				fmt.Fprintf(bw, `      <data key="%s">%s</data>`+"\n", attrs[i].name, escape(value))
+			}
+		}
+	}
+	for _, star := range g.stars {
+		fmt.Fprintf(bw, `    <node id="%d">`+"\n", g.StarId(star))
+		writeData(exportNodeAttrs, g.exportNode(star))
+		bw.WriteString("    </node>\n")
+	}
+	for _, edge := range g.undirectedEdges() {
+		fmt.Fprintf(bw, `    <edge source="%d" target="%d">`+"\n", g.StarId(edge.From), g.StarId(edge.To))
+		writeData(exportEdgeAttrs, g.exportEdge(edge))
+		bw.WriteString("    </edge>\n")
+	}
+
+	bw.WriteString("  </graph>\n</graphml>\n")
+	return bw.Flush()
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Stars are pinned to
+// their positions, so neato -n draws the galaxy as in game.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+	writeAttrs := func(attrs []exportAttr, values []string, extra ...string) {
+		list := extra
+		for i, value := range values {
+			if value != "" {
+				list = append(list, attrs[i].name+"="+quote(value))
+			}
+		}
+		fmt.Fprintf(bw, " [%s];\n", strings.Join(list, ", "))
+	}
+
+	bw.WriteString("graph galaxy {\n")
+	for _, star := range g.stars {
+		fmt.Fprintf(bw, "  %d", g.StarId(star))
+		writeAttrs(exportNodeAttrs, g.exportNode(star),
+			"label="+quote(star.Name()),
+			// Map is mirrored horizontally and y axis of Graphviz grows up
+			fmt.Sprintf(`pos="%g,%g!"`, 0-star.Coordinate.X, 0-star.Coordinate.Y))
+	}
+	for _, edge := range g.undirectedEdges() {
+		fmt.Fprintf(bw, "  %d -- %d", g.StarId(edge.From), g.StarId(edge.To))
+		extra := []string{}
+		if edge.Type != EdgeHyperlane {
+			extra = append(extra, "style=dashed")
+		}
+		writeAttrs(exportEdgeAttrs, g.exportEdge(edge), extra...)
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
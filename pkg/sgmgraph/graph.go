@@ -0,0 +1,256 @@
+package sgmgraph
+
+import (
+	"sort"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+const (
+	// Hyper relays allow jumping over this many relay systems at once
+	relayMaxJumps = 3
+)
+
+type EdgeType int
+
+const (
+	EdgeHyperlane EdgeType = iota
+	EdgeWormhole
+	EdgeGateway
+	EdgeLGate
+	EdgeHyperRelay
+
+	EdgeTypeMax
+)
+
+func (t EdgeType) String() string {
+	return [...]string{
+		"hyperlane",
+		"wormhole",
+		"gateway",
+		"lgate",
+		"relay",
+		"undefined",
+	}[t]
+}
+
+var bypassEdgeTypes = map[string]EdgeType{
+	sgm.BypassWormhole: EdgeWormhole,
+	sgm.BypassGateway:  EdgeGateway,
+	sgm.BypassLGate:    EdgeLGate,
+}
+
+// Edge is a single jump between two systems. Bypasses are instant, so their
+// distance is zero.
+type Edge struct {
+	From *sgm.Star
+	To   *sgm.Star
+	Type EdgeType
+
+	Jumps    int
+	Distance float64
+}
+
+type Options struct {
+	UseWormholes   bool
+	UseGateways    bool
+	UseLGates      bool
+	UseHyperRelays bool
+
+	// Systems which are closed for or hostile to CountryId are not used
+	// as intermediate steps of the path
+	CountryId          sgm.CountryId
+	AvoidClosedBorders bool
+	AvoidHostile       bool
+}
+
+// Graph is a navigation graph over hyperlanes and bypasses of the galaxy
+type Graph struct {
+	state *sgm.GameState
+	opts  Options
+
+	stars   []*sgm.Star
+	starIds map[*sgm.Star]sgm.StarId
+	edges   map[*sgm.Star][]Edge
+	blocked map[*sgm.Star]struct{}
+}
+
+func NewGraph(state *sgm.GameState, opts Options) *Graph {
+	g := &Graph{
+		state: state,
+		opts:  opts,
+
+		starIds: make(map[*sgm.Star]sgm.StarId),
+		edges:   make(map[*sgm.Star][]Edge),
+		blocked: make(map[*sgm.Star]struct{}),
+	}
+
+	for starId, star := range state.Stars {
+		if star != nil {
+			g.stars = append(g.stars, star)
+			g.starIds[star] = starId
+		}
+	}
+	sort.Slice(g.stars, func(i, j int) bool {
+		return g.starIds[g.stars[i]] < g.starIds[g.stars[j]]
+	})
+
+	for _, star := range g.stars {
+		g.addHyperlaneEdges(star)
+		g.addBypassEdges(star)
+		if opts.UseHyperRelays && star.HasHyperRelay() {
+			g.addRelayEdges(star)
+		}
+		if g.isBlocked(star) {
+			g.blocked[star] = struct{}{}
+		}
+	}
+	return g
+}
+
+// Stars returns all stars of the graph ordered by their ids
+func (g *Graph) Stars() []*sgm.Star {
+	return g.stars
+}
+
+func (g *Graph) StarId(star *sgm.Star) sgm.StarId {
+	if starId, ok := g.starIds[star]; ok {
+		return starId
+	}
+	return sgm.DefaultStarId
+}
+
+func (g *Graph) Edges(star *sgm.Star) []Edge {
+	return g.edges[star]
+}
+
+// IsBlocked returns true if path shouldn't go through the star
+func (g *Graph) IsBlocked(star *sgm.Star) bool {
+	_, blocked := g.blocked[star]
+	return blocked
+}
+
+func (g *Graph) addHyperlaneEdges(star *sgm.Star) {
+	for _, hyperlane := range star.Hyperlanes {
+		if hyperlane.To == nil {
+			continue
+		}
+		g.edges[star] = append(g.edges[star], Edge{
+			From:     star,
+			To:       hyperlane.To,
+			Type:     EdgeHyperlane,
+			Jumps:    1,
+			Distance: star.Point().Distance(hyperlane.To.Point()),
+		})
+	}
+}
+
+func (g *Graph) addBypassEdges(star *sgm.Star) {
+	for _, bypass := range star.BypassRefs {
+		edgeType, ok := bypassEdgeTypes[bypass.Type]
+		if !ok || !g.useEdgeType(edgeType) {
+			continue
+		}
+		if bypass.Network == nil || !bypass.IsUsable() {
+			continue
+		}
+
+		for _, other := range bypass.Network.Bypasses {
+			if other == bypass || other.Star == nil || other.Star == star || !other.IsUsable() {
+				continue
+			}
+			g.edges[star] = append(g.edges[star], Edge{
+				From:  star,
+				To:    other.Star,
+				Type:  edgeType,
+				Jumps: 1,
+			})
+		}
+	}
+}
+
+// addRelayEdges connects relay systems which are reachable via a chain of
+// relays of the same owner with a single jump
+func (g *Graph) addRelayEdges(star *sgm.Star) {
+	owner := star.Owner()
+	distances := map[*sgm.Star]float64{star: 0}
+	front := []*sgm.Star{star}
+	for jump := 1; jump <= relayMaxJumps && len(front) > 0; jump++ {
+		var nextFront []*sgm.Star
+		for _, from := range front {
+			for _, hyperlane := range from.Hyperlanes {
+				to := hyperlane.To
+				if to == nil || !to.HasHyperRelay() || to.Owner() != owner {
+					continue
+				}
+				if _, visited := distances[to]; visited {
+					continue
+				}
+
+				distances[to] = distances[from] + from.Point().Distance(to.Point())
+				nextFront = append(nextFront, to)
+				if jump > 1 {
+					g.edges[star] = append(g.edges[star], Edge{
+						From:     star,
+						To:       to,
+						Type:     EdgeHyperRelay,
+						Jumps:    1,
+						Distance: distances[to],
+					})
+				}
+			}
+		}
+		front = nextFront
+	}
+}
+
+func (g *Graph) useEdgeType(edgeType EdgeType) bool {
+	switch edgeType {
+	case EdgeWormhole:
+		return g.opts.UseWormholes
+	case EdgeGateway:
+		return g.opts.UseGateways
+	case EdgeLGate:
+		return g.opts.UseLGates
+	case EdgeHyperRelay:
+		return g.opts.UseHyperRelays
+	}
+	return true
+}
+
+func (g *Graph) isBlocked(star *sgm.Star) bool {
+	if !g.opts.AvoidClosedBorders && !g.opts.AvoidHostile {
+		return false
+	}
+
+	ownerId := star.Owner()
+	if ownerId == sgm.DefaultCountryId || ownerId == g.opts.CountryId {
+		return false
+	}
+	owner := g.state.Countries[ownerId]
+	if owner == nil {
+		return false
+	}
+
+	if g.opts.AvoidClosedBorders {
+		if rel := owner.Relation(g.opts.CountryId); rel != nil && rel.ClosedBorders {
+			return true
+		}
+	}
+	return g.opts.AvoidHostile && isHostileTo(g.state, g.opts.CountryId, star)
+}
+
+// isHostileTo returns true if star is owned by a crisis or a country which
+// is at war with the specified country
+func isHostileTo(state *sgm.GameState, countryId sgm.CountryId, star *sgm.Star) bool {
+	ownerId := star.Owner()
+	if ownerId == sgm.DefaultCountryId || ownerId == countryId {
+		return false
+	}
+	if owner := state.Countries[ownerId]; owner != nil && owner.IsCrisis() {
+		return true
+	}
+
+	country := state.Countries[countryId]
+	return country != nil && sgm.ComputeWarRole(countryId, country, star) == sgm.WarRoleStarAttacker
+}
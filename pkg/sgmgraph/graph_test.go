@@ -0,0 +1,245 @@
+package sgmgraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// newTestState creates a ring of 8 stars:
+//
+//	0 - 1 - 2 - 3
+//	|           |
+//	4 - 5 - 6 - 7
+//
+// where star 1 belongs to country 1 which closed borders for country 0,
+// and a pair of wormholes connects stars 0 and 3.
+func newTestState() *sgm.GameState {
+	state := &sgm.GameState{
+		Stars: make(map[sgm.StarId]*sgm.Star),
+		Countries: map[sgm.CountryId]*sgm.Country{
+			0: {},
+			1: {},
+		},
+	}
+	state.Countries[1].RelationsManager.Relations = []sgm.Relation{
+		{CountryId: 0, ClosedBorders: true},
+	}
+
+	for i := 0; i < 8; i++ {
+		star := &sgm.Star{}
+		star.Coordinate.X = float64(i%4) * 10
+		star.Coordinate.Y = float64(i/4) * 10
+		state.Stars[sgm.StarId(i)] = star
+	}
+	state.Stars[1].Sector = &sgm.Sector{Owner: 1}
+
+	link := func(from, to sgm.StarId) {
+		state.Stars[from].Hyperlanes = append(state.Stars[from].Hyperlanes,
+			sgm.Hyperlane{ToId: to, To: state.Stars[to]})
+		state.Stars[to].Hyperlanes = append(state.Stars[to].Hyperlanes,
+			sgm.Hyperlane{ToId: from, To: state.Stars[from]})
+	}
+	link(0, 1)
+	link(1, 2)
+	link(2, 3)
+	link(0, 4)
+	link(4, 5)
+	link(5, 6)
+	link(6, 7)
+	link(7, 3)
+
+	network := &sgm.BypassNetwork{Type: sgm.BypassWormhole}
+	for _, starId := range []sgm.StarId{0, 3} {
+		bypass := &sgm.Bypass{Type: sgm.BypassWormhole, Star: state.Stars[starId], Network: network}
+		network.Bypasses = append(network.Bypasses, bypass)
+		state.Stars[starId].BypassRefs = append(state.Stars[starId].BypassRefs, bypass)
+	}
+	return state
+}
+
+func routeStarIds(g *Graph, route *Route) (starIds []sgm.StarId) {
+	for _, star := range route.Stars {
+		starIds = append(starIds, g.StarId(star))
+	}
+	return
+}
+
+func TestShortestPath(t *testing.T) {
+	state := newTestState()
+
+	g := NewGraph(state, Options{})
+	route := g.ShortestPath(state.Stars[0], state.Stars[3], MetricJumps)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, []sgm.StarId{0, 1, 2, 3}, routeStarIds(g, route))
+		assert.Equal(t, 3, route.Jumps)
+		assert.InDelta(t, 30.0, route.Distance, 1e-6)
+	}
+
+	route = g.ShortestPath(state.Stars[5], state.Stars[5], MetricDistance)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, []sgm.StarId{5}, routeStarIds(g, route))
+		assert.Equal(t, 0, route.Jumps)
+	}
+}
+
+func TestShortestPathWormholes(t *testing.T) {
+	state := newTestState()
+
+	g := NewGraph(state, Options{UseWormholes: true})
+	route := g.ShortestPath(state.Stars[4], state.Stars[3], MetricJumps)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, []sgm.StarId{4, 0, 3}, routeStarIds(g, route))
+		assert.Equal(t, EdgeWormhole, route.Edges[1].Type)
+		assert.InDelta(t, 10.0, route.Distance, 1e-6)
+	}
+}
+
+func TestShortestPathClosedBorders(t *testing.T) {
+	state := newTestState()
+
+	g := NewGraph(state, Options{CountryId: 0, AvoidClosedBorders: true})
+	route := g.ShortestPath(state.Stars[0], state.Stars[2], MetricJumps)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, []sgm.StarId{0, 4, 5, 6, 7, 3, 2}, routeStarIds(g, route))
+	}
+
+	// Closed systems can still be a destination
+	route = g.ShortestPath(state.Stars[0], state.Stars[1], MetricJumps)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, []sgm.StarId{0, 1}, routeStarIds(g, route))
+	}
+}
+
+func TestFindChokepoints(t *testing.T) {
+	state := newTestState()
+	for _, starId := range []sgm.StarId{4, 5, 6} {
+		state.Stars[starId].Sector = &sgm.Sector{Owner: 0}
+	}
+	state.Stars[0].Sector = &sgm.Sector{Owner: 1}
+
+	g := NewGraph(state, Options{})
+	report := g.FindChokepoints(0)
+
+	var articulations, cutStars []sgm.StarId
+	for _, cp := range report.Chokepoints {
+		if cp.IsArticulation {
+			articulations = append(articulations, cp.StarId)
+		}
+		if len(cp.CutNeighborIds) > 0 {
+			assert.Equal(t, []sgm.CountryId{1}, cp.CutNeighborIds)
+			cutStars = append(cutStars, cp.StarId)
+		}
+		assert.False(t, cp.IsDefended())
+	}
+	assert.Equal(t, []sgm.StarId{5}, articulations)
+	assert.Equal(t, []sgm.StarId{4, 6}, cutStars)
+
+	if assert.Len(t, report.Cuts, 1) {
+		assert.Equal(t, sgm.CountryId(1), report.Cuts[0].NeighborId)
+		assert.Len(t, report.Cuts[0].Edges, 2)
+	}
+}
+
+func TestFindChokepointsThirdCountry(t *testing.T) {
+	// Country 0 reaches country 1 either through star 0 of country 2 or
+	// through unclaimed stars 7, 3 and 2
+	state := newTestState()
+	for _, starId := range []sgm.StarId{4, 5, 6} {
+		state.Stars[starId].Sector = &sgm.Sector{Owner: 0}
+	}
+	state.Countries[2] = &sgm.Country{}
+	state.Stars[0].Sector = &sgm.Sector{Owner: 2}
+
+	g := NewGraph(state, Options{})
+	report := g.FindChokepoints(0)
+	for _, cp := range report.Chokepoints {
+		assert.True(t, cp.Star.IsOwnedBy(0), "star %d", cp.StarId)
+	}
+
+	if assert.Len(t, report.Cuts, 2) {
+		cut := report.Cuts[0]
+		assert.Equal(t, sgm.CountryId(1), cut.NeighborId)
+		if assert.Len(t, cut.Edges, 1) {
+			assert.Equal(t, sgm.StarId(6), g.StarId(cut.Edges[0].From))
+			assert.Equal(t, sgm.StarId(7), g.StarId(cut.Edges[0].To))
+		}
+
+		cut = report.Cuts[1]
+		assert.Equal(t, sgm.CountryId(2), cut.NeighborId)
+		if assert.Len(t, cut.Edges, 1) {
+			assert.Equal(t, sgm.StarId(4), g.StarId(cut.Edges[0].From))
+		}
+	}
+}
+
+func TestComputeThreats(t *testing.T) {
+	state := newTestState()
+	for _, starId := range []sgm.StarId{4, 5, 6} {
+		state.Stars[starId].Sector = &sgm.Sector{Owner: 0}
+	}
+
+	war := &sgm.War{
+		Attackers: []sgm.WarCountry{{CountryId: 1}},
+		Defenders: []sgm.WarCountry{{CountryId: 0}},
+	}
+	state.Countries[0].Wars = []sgm.WarRef{{War: war}}
+	state.Countries[1].Wars = []sgm.WarRef{{War: war, IsAttacker: true}}
+
+	fleet := &sgm.Fleet{
+		Mobile:        true,
+		MilitaryPower: 1200,
+		OwnerId:       1,
+		Owner:         state.Countries[1],
+		Ships:         []*sgm.Ship{{ArmyId: sgm.DefaultArmyId}},
+	}
+	state.Stars[7].Fleets = []*sgm.Fleet{fleet}
+
+	g := NewGraph(state, Options{})
+	threats := g.ComputeThreats(0, ThreatOptions{MaxJumps: 2, WeightByDistance: true})
+	if assert.Len(t, threats, 2) {
+		assert.Equal(t, sgm.StarId(6), threats[0].StarId)
+		assert.InDelta(t, 600.0, threats[0].Power, 1e-6)
+		assert.Equal(t, sgm.StarId(5), threats[1].StarId)
+		assert.InDelta(t, 400.0, threats[1].Power, 1e-6)
+	}
+
+	assert.Empty(t, g.ComputeThreats(1, ThreatOptions{}))
+
+	// Fleet is outside of sensor range
+	threats = g.ComputeThreats(0, ThreatOptions{
+		IsObserved: func(star *sgm.Star) bool { return star != state.Stars[7] },
+	})
+	assert.Empty(t, threats)
+}
+
+func TestJumpField(t *testing.T) {
+	state := newTestState()
+
+	g := NewGraph(state, Options{})
+	jumps := g.JumpField([]*sgm.Star{state.Stars[0], state.Stars[6]}, 0)
+	expected := map[sgm.StarId]int{0: 0, 1: 1, 2: 2, 3: 2, 4: 1, 5: 1, 6: 0, 7: 1}
+	for starId, star := range state.Stars {
+		assert.Equal(t, expected[starId], jumps[star], "star %d", starId)
+	}
+
+	jumps = g.JumpField([]*sgm.Star{state.Stars[0]}, 2)
+	assert.Len(t, jumps, 5)
+}
+
+func TestWriteDOT(t *testing.T) {
+	state := newTestState()
+
+	var buf strings.Builder
+	err := NewGraph(state, Options{UseWormholes: true}).WriteDOT(&buf)
+	if assert.NoError(t, err) {
+		dot := buf.String()
+		assert.Equal(t, 9, strings.Count(dot, " -- "))
+		assert.Equal(t, 8, strings.Count(dot, `type="hyperlane"`))
+		assert.Contains(t, dot, `0 -- 3 [style=dashed, type="wormhole", distance="0"];`)
+		assert.Contains(t, dot, `1 [label="", pos="-10,0!", x="10", y="0", owner_id="1"`)
+	}
+}
@@ -0,0 +1,142 @@
+package sgmgraph
+
+import (
+	"container/heap"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+type Metric int
+
+const (
+	MetricJumps Metric = iota
+	MetricDistance
+
+	MetricMax
+)
+
+func (m Metric) String() string {
+	return [...]string{
+		"jumps",
+		"distance",
+		"undefined",
+	}[m]
+}
+
+func (m Metric) cost(edge Edge) float64 {
+	if m == MetricDistance {
+		return edge.Distance
+	}
+	return float64(edge.Jumps)
+}
+
+type Route struct {
+	Stars []*sgm.Star
+	Edges []Edge
+
+	Jumps    int
+	Distance float64
+}
+
+type searchItem struct {
+	star *sgm.Star
+	cost float64
+	seq  int
+}
+
+type searchQueue []searchItem
+
+func (q searchQueue) Len() int { return len(q) }
+func (q searchQueue) Less(i, j int) bool {
+	if q[i].cost == q[j].cost {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].cost < q[j].cost
+}
+func (q searchQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *searchQueue) Push(x interface{}) { *q = append(*q, x.(searchItem)) }
+func (q *searchQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+type searchResult struct {
+	costs map[*sgm.Star]float64
+	prev  map[*sgm.Star]Edge
+}
+
+// search runs Dijkstra from the sources until target is reached (if it is
+// not nil). Blocked systems can be reached, but are not expanded unless
+// they are one of the sources.
+func (g *Graph) search(sources []*sgm.Star, target *sgm.Star, metric Metric) searchResult {
+	res := searchResult{
+		costs: make(map[*sgm.Star]float64),
+		prev:  make(map[*sgm.Star]Edge),
+	}
+
+	queue := &searchQueue{}
+	seq := 0
+	isSource := make(map[*sgm.Star]struct{})
+	for _, source := range sources {
+		res.costs[source] = 0
+		isSource[source] = struct{}{}
+		heap.Push(queue, searchItem{star: source, seq: seq})
+		seq++
+	}
+
+	done := make(map[*sgm.Star]struct{})
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(searchItem)
+		if _, isDone := done[item.star]; isDone {
+			continue
+		}
+		done[item.star] = struct{}{}
+		if item.star == target {
+			break
+		}
+		if _, ok := isSource[item.star]; !ok && g.IsBlocked(item.star) {
+			continue
+		}
+
+		for _, edge := range g.edges[item.star] {
+			cost := item.cost + metric.cost(edge)
+			if prevCost, ok := res.costs[edge.To]; ok && prevCost <= cost {
+				continue
+			}
+
+			res.costs[edge.To] = cost
+			res.prev[edge.To] = edge
+			heap.Push(queue, searchItem{star: edge.To, cost: cost, seq: seq})
+			seq++
+		}
+	}
+	return res
+}
+
+// ShortestPath returns the shortest route between two systems or nil if
+// there is no such route
+func (g *Graph) ShortestPath(from, to *sgm.Star, metric Metric) *Route {
+	res := g.search([]*sgm.Star{from}, to, metric)
+	if _, ok := res.costs[to]; !ok {
+		return nil
+	}
+
+	var edges []Edge
+	for star := to; star != from; {
+		edge := res.prev[star]
+		edges = append(edges, edge)
+		star = edge.From
+	}
+
+	route := &Route{Stars: []*sgm.Star{from}}
+	for i := len(edges) - 1; i >= 0; i-- {
+		edge := edges[i]
+		route.Edges = append(route.Edges, edge)
+		route.Stars = append(route.Stars, edge.To)
+		route.Jumps += edge.Jumps
+		route.Distance += edge.Distance
+	}
+	return route
+}
@@ -0,0 +1,113 @@
+package sgmgraph
+
+import (
+	"sort"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+const (
+	DefaultThreatJumps = 3
+)
+
+type ThreatOptions struct {
+	// Fleets which are farther than this are not considered as threat
+	MaxJumps int
+	// Divide fleet power by number of jumps it needs to reach the system plus one
+	WeightByDistance bool
+	// If set, fleets in systems for which it returns false are not known to
+	// the country and ignored, i.e. outside of its sensor range
+	IsObserved func(star *sgm.Star) bool
+}
+
+// Threat is an estimate of hostile military power which can reach the
+// system of the country
+type Threat struct {
+	StarId sgm.StarId
+	Star   *sgm.Star
+
+	Power  float64
+	Fleets []*sgm.Fleet
+}
+
+// ComputeThreats returns threats to all systems of the country which are
+// reachable by hostile fleets sorted by power
+func (g *Graph) ComputeThreats(countryId sgm.CountryId, opts ThreatOptions) []*Threat {
+	if opts.MaxJumps <= 0 {
+		opts.MaxJumps = DefaultThreatJumps
+	}
+
+	// War role only depends on the owner of the system, so any system of
+	// the country would do for checking if fleet is hostile
+	var home *sgm.Star
+	for _, star := range g.stars {
+		if star.IsOwnedBy(countryId) {
+			home = star
+			break
+		}
+	}
+	if home == nil {
+		return nil
+	}
+
+	threats := make(map[*sgm.Star]*Threat)
+	for _, star := range g.stars {
+		if opts.IsObserved != nil && !opts.IsObserved(star) {
+			continue
+		}
+
+		var fleets []*sgm.Fleet
+		for _, fleet := range star.MobileMilitaryFleets() {
+			if isFleetHostileTo(countryId, fleet, home) {
+				fleets = append(fleets, fleet)
+			}
+		}
+		if len(fleets) == 0 {
+			continue
+		}
+
+		for target, jumps := range g.JumpField([]*sgm.Star{star}, opts.MaxJumps) {
+			if !target.IsOwnedBy(countryId) {
+				continue
+			}
+
+			threat := threats[target]
+			if threat == nil {
+				threat = &Threat{StarId: g.StarId(target), Star: target}
+				threats[target] = threat
+			}
+			for _, fleet := range fleets {
+				power := fleet.MilitaryPower
+				if opts.WeightByDistance {
+					power /= float64(jumps + 1)
+				}
+				threat.Power += power
+				threat.Fleets = append(threat.Fleets, fleet)
+			}
+		}
+	}
+
+	result := make([]*Threat, 0, len(threats))
+	for _, threat := range threats {
+		result = append(result, threat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Power == result[j].Power {
+			return result[i].StarId < result[j].StarId
+		}
+		return result[i].Power > result[j].Power
+	})
+	return result
+}
+
+// isFleetHostileTo returns true if fleet belongs to crisis or to a country
+// which is at war with the specified country
+func isFleetHostileTo(countryId sgm.CountryId, fleet *sgm.Fleet, home *sgm.Star) bool {
+	if fleet.Owner == nil || fleet.OwnerId == countryId {
+		return false
+	}
+	if fleet.Owner.IsCrisis() {
+		return true
+	}
+	return sgm.ComputeWarRole(fleet.OwnerId, fleet.Owner, home) == sgm.WarRoleStarAttacker
+}
@@ -16,6 +16,14 @@ func (p Point) Add(diff Point) Point {
 	return Point{X: p.X + diff.X, Y: p.Y + diff.Y}
 }
 
+func (p Point) Sub(other Point) Point {
+	return Point{X: p.X - other.X, Y: p.Y - other.Y}
+}
+
+func (p Point) Mul(k float64) Point {
+	return Point{X: p.X * k, Y: p.Y * k}
+}
+
 func (p Point) Distance(other Point) float64 {
 	v := Vector{p, other}
 	return v.ToPolar().Length
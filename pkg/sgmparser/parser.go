@@ -129,40 +129,50 @@ func (p *Parser) prepareFields(v reflect.Value) map[string]reflect.Value {
 }
 
 func (p *Parser) parseStruct(terminalTokenType TokenType, v reflect.Value) error {
-	fields := p.prepareFields(v)
+	return p.parseFields(terminalTokenType, p.prepareFields(v))
+}
+
+func (p *Parser) parseFields(terminalTokenType TokenType, fields map[string]reflect.Value) error {
 	for token := range p.tokenizer.C {
 		if token.Type == terminalTokenType {
 			return nil
 		}
-		if err := p.ensureToken(token, TokenIdentifier); err != nil {
+		if err := p.parseField(fields, token); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		eqToken := <-p.tokenizer.C
-		if err := p.ensureToken(eqToken, TokenEqualSign); err != nil {
-			return err
-		}
+func (p *Parser) parseField(fields map[string]reflect.Value, token Token) error {
+	if err := p.ensureToken(token, TokenIdentifier); err != nil {
+		return err
+	}
 
-		valueToken := <-p.tokenizer.C
-		field, hasField := fields[token.Value]
-		if !hasField {
-			if valueToken.Type == TokenCollectionStart {
-				p.ignoreComplexValue()
-			}
-			continue
-		}
+	eqToken := <-p.tokenizer.C
+	if err := p.ensureToken(eqToken, TokenEqualSign); err != nil {
+		return err
+	}
 
-		if valueToken.Type == TokenCollectionStart && field.Kind() != reflect.Struct {
-			if structField, hasStruct := fields[token.Value+",struct"]; hasStruct {
-				field = structField
-			}
+	valueToken := <-p.tokenizer.C
+	field, hasField := fields[token.Value]
+	if !hasField {
+		if valueToken.Type == TokenCollectionStart {
+			p.ignoreComplexValue()
 		}
+		return nil
+	}
 
-		err := p.parseValue(valueToken, field)
-		if err != nil {
-			return WrapParserError(token.Value, err)
+	if valueToken.Type == TokenCollectionStart && field.Kind() != reflect.Struct {
+		if structField, hasStruct := fields[token.Value+",struct"]; hasStruct {
+			field = structField
 		}
 	}
+
+	err := p.parseValue(valueToken, field)
+	if err != nil {
+		return WrapParserError(token.Value, err)
+	}
 	return nil
 }
 
@@ -238,6 +248,21 @@ func (p *Parser) parseValue(valueToken Token, v reflect.Value) error {
 				break
 			}
 
+			if elToken.Type == TokenIdentifier && v.Type().Elem().Kind() == reflect.Struct {
+				// Not a list, but a key repeated with struct values, i.e.
+				// key={ field=value } key={ field=value }
+				el := reflect.New(v.Type().Elem()).Elem()
+				fields := p.prepareFields(el)
+				if err := p.parseField(fields, elToken); err != nil {
+					return err
+				}
+				if err := p.parseFields(TokenCollectionEnd, fields); err != nil {
+					return err
+				}
+				v.Set(reflect.Append(v, el))
+				break
+			}
+
 			el := reflect.New(v.Type().Elem()).Elem()
 			err := p.parseValue(elToken, el)
 			if err != nil {
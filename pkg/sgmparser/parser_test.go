@@ -49,7 +49,6 @@ func TestParser(t *testing.T) {
 	parser := NewParser(NewTokenizer(bytes.NewBufferString(simpleObject)))
 	parserErr := parser.Parse(&value)
 
-	assert.NoError(t, tokenizerErr)
 	assert.NoError(t, parserErr)
 
 	assert.Equal(t, simpleState{
@@ -60,3 +59,35 @@ func TestParser(t *testing.T) {
 		},
 	}, value)
 }
+
+type repeatedState struct {
+	Relations []struct {
+		Country uint32 `sgm:"country,id"`
+		Contact bool   `sgm:"contact"`
+	} `sgm:"relation"`
+	Lanes []struct {
+		To uint32 `sgm:"to"`
+	} `sgm:"hyperlane"`
+}
+
+const repeatedObject = `
+relation={ owner=0 country=1 contact=yes }
+relation={ contact=no country=2 }
+hyperlane={ { to=3 length=10 } { to=4 length=12 } }
+`
+
+func TestParserRepeatedStruct(t *testing.T) {
+	var value repeatedState
+	parser := NewParser(NewTokenizer(bytes.NewBufferString(repeatedObject)))
+	assert.NoError(t, parser.Parse(&value))
+
+	if assert.Len(t, value.Relations, 2) {
+		assert.Equal(t, uint32(1), value.Relations[0].Country)
+		assert.True(t, value.Relations[0].Contact)
+		assert.Equal(t, uint32(2), value.Relations[1].Country)
+		assert.False(t, value.Relations[1].Contact)
+	}
+	if assert.Len(t, value.Lanes, 2) {
+		assert.Equal(t, uint32(4), value.Lanes[1].To)
+	}
+}
@@ -0,0 +1,143 @@
+package sgmrender
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	battleMarkerMinRadius = 1.2
+	battleMarkerMaxRadius = 6.0
+)
+
+type historicBattle struct {
+	warId  sgm.WarId
+	war    *sgm.War
+	battle *sgm.Battle
+	star   *sgm.Star
+}
+
+// isBattleShown returns true if battle matches war and period filters of
+// the battle history
+func (r *Renderer) isBattleShown(warId sgm.WarId, battle *sgm.Battle) bool {
+	if len(r.opts.BattleWars) > 0 {
+		found := false
+		for _, id := range r.opts.BattleWars {
+			if id == warId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	year := battle.Date.Year()
+	if r.opts.BattleFromYear > 0 && year < r.opts.BattleFromYear {
+		return false
+	}
+	if r.opts.BattleToYear > 0 && year > r.opts.BattleToYear {
+		return false
+	}
+	return true
+}
+
+func (r *Renderer) findHistoricBattles() (battles []historicBattle) {
+	for warId, war := range r.state.Wars {
+		for i := range war.Battles {
+			battle := &war.Battles[i]
+			star := r.state.Stars[battle.StarId]
+			if star == nil || !r.isExplored(star) || !r.isBattleShown(warId, battle) {
+				continue
+			}
+			battles = append(battles, historicBattle{warId, war, battle, star})
+		}
+	}
+
+	// Draw larger battles first so smaller ones in the same system
+	// remain visible on top of them
+	sort.Slice(battles, func(i, j int) bool {
+		li, lj := battles[i].battle.TotalLosses(), battles[j].battle.TotalLosses()
+		if li != lj {
+			return li > lj
+		}
+		return battles[i].battle.Date < battles[j].battle.Date
+	})
+	return
+}
+
+// renderBattleHistory draws a circle for every battle in selected wars
+// sized by total losses and colored by the side which won it
+func (r *Renderer) renderBattleHistory() {
+	if !r.opts.BattleHistory {
+		return
+	}
+
+	battles := r.findHistoricBattles()
+	if len(battles) == 0 {
+		return
+	}
+	maxLosses := battles[0].battle.TotalLosses()
+
+	g := r.layer.CreateGroup(Style{})
+	for _, hb := range battles {
+		radius := battleMarkerMinRadius
+		if maxLosses > 0 {
+			radius += (battleMarkerMaxRadius - battleMarkerMinRadius) *
+				math.Sqrt(float64(hb.battle.TotalLosses())/float64(maxLosses))
+		}
+
+		style, winner := r.styles.battleDefenderWonStyle, "defenders"
+		if hb.battle.AttackerVictory {
+			style, winner = r.styles.battleAttackerWonStyle, "attackers"
+		}
+		if hb.battle.Type == sgm.BattleTypeArmies {
+			style = style.With(StyleOption{"stroke-dasharray", "1.0,0.5"})
+		}
+
+		attackerId, defenderId := hb.war.Leaders()
+		circleEl := g.CreateCircle(style, hb.star.Point(), radius)
+		circleEl.SetTitle(fmt.Sprintf(
+			"Battle of %s (%s), %s: %s vs %s, %s won, losses %d / %d",
+			hb.star.Name(), hb.battle.Type, hb.battle.Date,
+			r.countryName(attackerId, r.state.Countries[attackerId]),
+			r.countryName(defenderId, r.state.Countries[defenderId]),
+			winner, hb.battle.AttackerLosses, hb.battle.DefenderLosses))
+	}
+
+	r.renderBattleLegend()
+}
+
+// renderBattleLegend explains marker colors under the map title, names war
+// leaders if only one war is shown
+func (r *Renderer) renderBattleLegend() {
+	attackers, defenders := "Attackers won", "Defenders won"
+	if len(r.opts.BattleWars) == 1 {
+		if war := r.state.Wars[r.opts.BattleWars[0]]; war != nil {
+			attackerId, defenderId := war.Leaders()
+			attackers = fmt.Sprintf("%s (%s)", attackers,
+				r.countryName(attackerId, r.state.Countries[attackerId]))
+			defenders = fmt.Sprintf("%s (%s)", defenders,
+				r.countryName(defenderId, r.state.Countries[defenderId]))
+		}
+	}
+
+	point := r.innerBounds.Min.Add(sgmmath.Point{X: battleMarkerMinRadius, Y: 2.5 * r.styles.countryFontSize})
+	for _, entry := range []struct {
+		style Style
+		text  string
+	}{
+		{r.styles.battleAttackerWonStyle, attackers},
+		{r.styles.battleDefenderWonStyle, defenders},
+	} {
+		r.layer.CreateCircle(entry.style, point, battleMarkerMinRadius)
+		r.layer.CreateText(r.styles.starTextStyle,
+			point.Add(sgmmath.Point{X: 2 * battleMarkerMinRadius, Y: r.styles.fontSize / 2}), entry.text)
+		point.Y += 1.5 * r.styles.fontSize
+	}
+}
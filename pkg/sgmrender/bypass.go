@@ -0,0 +1,93 @@
+package sgmrender
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	bypassArcBend = 0.2
+
+	// Networks with longer arcs (relative to galaxy size) or with more
+	// endpoints are labeled instead of being connected by arcs
+	bypassArcMaxLength  = 0.5
+	bypassArcMaxNetwork = 4
+)
+
+var bypassLabelPrefixes = map[string]string{
+	sgm.BypassWormhole: "W",
+	sgm.BypassGateway:  "G",
+	sgm.BypassLGate:    "L",
+}
+
+func (r *Renderer) renderBypassLinks() {
+	if !r.opts.ShowBypassLinks {
+		return
+	}
+
+	w, h := r.innerBounds.Size()
+	maxLength := bypassArcMaxLength * math.Max(w, h)
+
+	labelIndices := make(map[string]int)
+	for _, network := range r.state.BypassNetworks {
+		var stars []*sgm.Star
+		for _, bypass := range network.Bypasses {
+			if bypass.Star != nil && bypass.IsUsable() && r.isExplored(bypass.Star) {
+				stars = append(stars, bypass.Star)
+			}
+		}
+		if len(stars) < 2 {
+			continue
+		}
+
+		style := r.styles.bypassLinkStyle.With(StyleOption{"stroke", r.styles.bypassLinkColors[network.Type]})
+		if isBypassNetworkCompact(stars, maxLength) {
+			for i, star := range stars {
+				for _, other := range stars[i+1:] {
+					r.layer.CreatePath(style, newBypassArcPath(star.Point(), other.Point()))
+				}
+			}
+			continue
+		}
+
+		labelIndices[network.Type]++
+		label := fmt.Sprintf("%s%d", bypassLabelPrefixes[network.Type], labelIndices[network.Type])
+		textStyle := r.styles.bypassTextStyle.With(StyleOption{"fill", r.styles.bypassLinkColors[network.Type]})
+		for _, star := range stars {
+			point := star.Point().Add(sgmmath.Point{X: -starbaseHalfSize - 1, Y: r.styles.fontSize / 3})
+			textEl := r.layer.CreateText(textStyle, point, label)
+			textEl.SetTitle(fmt.Sprintf("%s network %s (%d systems)",
+				network.Type, label, len(stars)))
+		}
+	}
+}
+
+func isBypassNetworkCompact(stars []*sgm.Star, maxLength float64) bool {
+	if len(stars) > bypassArcMaxNetwork {
+		return false
+	}
+	for i, star := range stars {
+		for _, other := range stars[i+1:] {
+			if star.Point().Distance(other.Point()) > maxLength {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// newBypassArcPath creates an arc which is bent sideways so it won't be
+// confused with hyperlanes
+func newBypassArcPath(begin, end sgmmath.Point) Path {
+	return NewPath().MoveToPoint(begin).QuadTo(bypassArcControl(begin, end), end)
+}
+
+func bypassArcControl(begin, end sgmmath.Point) sgmmath.Point {
+	return sgmmath.Point{
+		X: (begin.X+end.X)/2 - (end.Y-begin.Y)*bypassArcBend,
+		Y: (begin.Y+end.Y)/2 + (end.X-begin.X)*bypassArcBend,
+	}
+}
@@ -0,0 +1,106 @@
+package sgmrender
+
+import (
+	"io"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// Element is a shape or a group drawn on canvas
+type Element interface {
+	// SetTitle attaches tooltip to the element
+	SetTitle(title string)
+	// SetId and SetData attach identifier and data-* attributes used by
+	// scripts of interactive maps. Static backends ignore them.
+	SetId(id string)
+	SetData(name, value string)
+	// Animate adds animation of the style property. Backends which produce
+	// static images ignore animations.
+	Animate(animation Animation)
+}
+
+type Animation struct {
+	Property string
+	From, To string
+	Duration string
+}
+
+// Group is a container of elements which share style and origin
+type Group interface {
+	Element
+
+	CreateGroup(style Style) Group
+	// CreateTranslatedGroup creates group with origin in the point
+	CreateTranslatedGroup(point sgmmath.Point) Group
+
+	CreatePath(style Style, path Path) Element
+	CreateRect(style Style, rect sgmmath.BoundingRect) Element
+	CreateCircle(style Style, center sgmmath.Point, radius float64) Element
+	// CreateText draws text with baseline starting in the point, use
+	// text-anchor property of the style to align it
+	CreateText(style Style, point sgmmath.Point, text string) Element
+	// CreateIcon draws embedded icon with its top left corner in the point
+	CreateIcon(point sgmmath.Point, name string, size float64) Element
+}
+
+// Canvas is a backend which draws the map in specific output format. Its
+// root group contains all elements drawn by renderer.
+type Canvas interface {
+	Group
+
+	// CreateLayer creates named group in the root which can be hidden by
+	// users of the map. Layers are drawn in order of creation.
+	CreateLayer(id, label string) Group
+	// CreatePattern defines square tile which can be used as fill of
+	// shapes as "url(#id)"
+	CreatePattern(id string, size float64) Group
+	// CreateLinearGradient defines horizontal gradient with evenly spread
+	// colors which can be used as fill of shapes as "url(#id)"
+	CreateLinearGradient(id string, colors []string)
+	HasDefinition(id string) bool
+
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// CanvasFactory creates canvas which shows area of the map in bounds as
+// an image of specified size
+type CanvasFactory func(bounds sgmmath.BoundingRect, width, height float64) Canvas
+
+// DetailLevel tells how far the map should be zoomed in to show element
+type DetailLevel int
+
+const (
+	// Countries, their names and overlays shown on the whole map
+	DetailCountries DetailLevel = iota
+	// Hyperlanes, stars, their names and fleets
+	DetailStars
+	// Starbases, planets, megastructures and other system icons
+	DetailSystems
+)
+
+var detailLevelNames = []string{"countries", "stars", "systems"}
+
+func (level DetailLevel) String() string {
+	return detailLevelNames[level]
+}
+
+// DetailCanvas is implemented by canvases which hide elements depending on
+// zoom. Renderer sets level of elements before drawing them.
+type DetailCanvas interface {
+	Canvas
+	SetDetailLevel(level DetailLevel)
+}
+
+// LayerControlCanvas is implemented by canvases which can embed controls
+// toggling visibility of layers
+type LayerControlCanvas interface {
+	Canvas
+	EnableLayerControls()
+}
+
+// DirCanvas is implemented by canvases which produce multiple files, so
+// they are written into a directory instead of a single file
+type DirCanvas interface {
+	Canvas
+	WriteDir(dir string) error
+}
@@ -0,0 +1,47 @@
+package sgmrender
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+func (r *Renderer) renderChokepoints() {
+	if !r.opts.ShowChokepoints {
+		return
+	}
+
+	graph := sgmgraph.NewGraph(r.state, sgmgraph.Options{})
+	report := graph.FindChokepoints(r.opts.ChokepointCountry)
+	for _, cp := range report.Chokepoints {
+		if cp.IsDefended() {
+			continue
+		}
+
+		var reasons []string
+		if cp.IsArticulation {
+			reasons = append(reasons, "splits territory")
+		}
+		if len(cp.CutNeighborIds) > 0 {
+			reasons = append(reasons, "border with "+r.countryNames(cp.CutNeighborIds))
+		}
+		if len(cp.HostileNeighborIds) > 0 {
+			reasons = append(reasons, "faces "+r.countryNames(cp.HostileNeighborIds))
+		}
+
+		p := cp.Star.Point()
+		g := r.layer.CreateTranslatedGroup(p)
+		g.SetTitle(fmt.Sprintf("Undefended chokepoint: %s", strings.Join(reasons, ", ")))
+		g.CreatePath(r.styles.chokepointStyle, chokepointPath)
+	}
+}
+
+func (r *Renderer) countryNames(countryIds []sgm.CountryId) string {
+	names := make([]string, 0, len(countryIds))
+	for _, countryId := range countryIds {
+		names = append(names, r.countryName(countryId, r.state.Countries[countryId]))
+	}
+	return strings.Join(names, ", ")
+}
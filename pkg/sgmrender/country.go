@@ -15,11 +15,6 @@ import (
 
 type countrySegFlag uint
 
-var (
-	DefaultCountryBorderColor = "#202020"
-	DefaultCountryFillColor   = "#c0c0c0"
-)
-
 const (
 	countrySegHasCapital countrySegFlag = 1 << iota
 	countrySegHasOuterEdge
@@ -86,6 +81,7 @@ type countryRenderer struct {
 
 type countryRenderContext struct {
 	country *sgm.Country
+	name    string
 	seg     *countrySegment
 	style   Style
 }
@@ -106,15 +102,23 @@ func (r *Renderer) createCountryRenderer() *countryRenderer {
 	}
 
 	bbox := voronoi.NewBBox(
-		r.bounds.Min.X-canvasPadding/2,
-		r.bounds.Max.X+canvasPadding/2,
-		r.bounds.Min.Y-canvasPadding/2,
-		r.bounds.Max.Y+canvasPadding/2)
+		r.galaxyBounds.Min.X-canvasPadding/2,
+		r.galaxyBounds.Max.X+canvasPadding/2,
+		r.galaxyBounds.Min.Y-canvasPadding/2,
+		r.galaxyBounds.Max.Y+canvasPadding/2)
 	cr.diagram = voronoi.ComputeDiagram(sites, bbox, true)
 
 	return cr
 }
 
+// getCountryRenderer returns Voronoi diagram shared by countries and overlays
+func (r *Renderer) getCountryRenderer() *countryRenderer {
+	if r.countryRenderer == nil {
+		r.countryRenderer = r.createCountryRenderer()
+	}
+	return r.countryRenderer
+}
+
 func (cr *countryRenderer) starByCell(cell *voronoi.Cell) (sgm.StarId, *sgm.Star) {
 	starId := cr.starMap[cell.Site]
 	return starId, cr.r.state.Stars[starId]
@@ -246,7 +250,7 @@ func (cr *countryRenderer) buildSegment(
 func (cr *countryRenderer) buildPoint(
 	cellPoint sgmmath.Point, ev voronoi.EdgeVertex, insetSign float64,
 ) sgmmath.Point {
-	point := sgmmath.Point{ev.X, ev.Y}
+	point := sgmmath.Point{X: ev.X, Y: ev.Y}
 	pv := sgmmath.NewVector(cellPoint, point).ToPolar()
 
 	if pv.Length > maxCellSize {
@@ -318,7 +322,6 @@ func (cr *countryRenderer) buildPath(seg *countrySegment) Path {
 }
 
 func getCountryMapColor(key string, defaultColor string) string {
-	// TODO: handle "use_as_border_color", cases when colors are not found
 	color := sgm.ColorMap.Colors[key]
 	if color == nil {
 		return defaultColor
@@ -326,9 +329,33 @@ func getCountryMapColor(key string, defaultColor string) string {
 	return color.Map.Color().ToHexCode().String()
 }
 
+// getCountryFlagColors returns border and fill colors of the country from
+// the primary and secondary colors of its flag. If secondary color is
+// missing or can't be used as border, border is the darkened fill.
+func getCountryFlagColors(flagColors []string) (string, string, bool) {
+	if len(flagColors) == 0 {
+		return "", "", false
+	}
+	fill := getCountryMapColor(flagColors[0], "")
+	if fill == "" {
+		return "", "", false
+	}
+
+	if len(flagColors) >= 2 {
+		if color := sgm.ColorMap.Colors[flagColors[1]]; color != nil && color.IsBorderColor() {
+			return color.Map.Color().ToHexCode().String(), fill, true
+		}
+	}
+	return darkenColor(fill, countryBorderLightness), fill, true
+}
+
 func (r *Renderer) renderCountries() []countryRenderContext {
-	cr := r.createCountryRenderer()
+	cr := r.getCountryRenderer()
 	segments := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		if !r.isExplored(s) {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+
 		ownerId := s.Owner()
 		if r.opts.ShowSectors {
 			return ownerId, uint64(s.SectorId) | (uint64(ownerId) << 32)
@@ -338,24 +365,41 @@ func (r *Renderer) renderCountries() []countryRenderContext {
 	countries := make([]countryRenderContext, 0, len(segments))
 	for _, seg := range segments {
 		country := r.state.Countries[seg.countryId]
-		style := baseCountryStyle.With(
-			StyleOption{"stroke", getCountryMapColor(country.Flag.Colors[1], DefaultCountryBorderColor)},
-			StyleOption{"fill", getCountryMapColor(country.Flag.Colors[0], DefaultCountryFillColor)},
+		if country.IsCrisis() && r.isCountryKnown(seg.countryId) && r.war == nil {
+			countries = append(countries, r.renderCrisisSegment(cr, seg, country))
+			continue
+		}
+
+		strokeColor, fillColor := r.countryMapColors(seg.countryId, country)
+		style := r.styles.baseCountryStyle.With(
+			StyleOption{"stroke", strokeColor},
+			StyleOption{"fill", fillColor},
 		)
 
-		r.createPath(r.canvas, style, cr.buildPath(seg))
+		name := r.countryName(seg.countryId, country)
+		path := cr.buildPath(seg)
+		r.annotateCountry(r.layer.CreatePath(style, path), seg, name)
+		if r.opts.ShowHumans && country.IsHuman() && r.isCountryKnown(seg.countryId) {
+			r.layer.CreatePath(r.styles.humanCountryStyle, path)
+		}
 
 		countries = append(countries, countryRenderContext{
 			country: country,
+			name:    name,
 			seg:     seg,
 			style:   style,
 		})
 	}
 
 	occupiedSegs := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		if !r.isExplored(s) {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+
 		occupierId := s.Occupier()
 		return occupierId, uint64(occupierId)
 	})
+	r.useLayer(layerOccupation)
 	occupierPatters := make(map[sgm.CountryId]struct{})
 	for _, seg := range occupiedSegs {
 		country := r.state.Countries[seg.countryId]
@@ -365,16 +409,21 @@ func (r *Renderer) renderCountries() []countryRenderContext {
 			occupierPatters[seg.countryId] = struct{}{}
 		}
 
-		style := occupationCountryStyle.With(
-			StyleOption{"stroke", getCountryMapColor(country.Flag.Colors[1], DefaultCountryBorderColor)},
+		strokeColor, _ := r.countryMapColors(seg.countryId, country)
+		style := r.styles.occupationCountryStyle
+		if r.war != nil {
+			style = r.styles.warOccupiedStyle
+		}
+		style = style.With(
+			StyleOption{"stroke", strokeColor},
 			StyleOption{"fill", fmt.Sprintf("url(#%s)", patternId)},
 		)
-		r.createPath(r.canvas, style, cr.buildPath(seg))
+		r.layer.CreatePath(style, cr.buildPath(seg))
 	}
 
 	if traceFlags&traceFlagShowGraphEdges != 0 {
 		for _, edge := range cr.diagram.Edges {
-			r.createPath(r.canvas, hyperlaneStyle,
+			r.layer.CreatePath(r.styles.hyperlaneStyle,
 				NewPath().MoveTo(edge.Va.X, edge.Va.Y).LineTo(edge.Vb.X, edge.Vb.Y))
 		}
 	}
@@ -383,21 +432,13 @@ func (r *Renderer) renderCountries() []countryRenderContext {
 }
 
 func (r *Renderer) createCountryPattern(countryId sgm.CountryId, id string) {
-	pattern := r.defs.CreateElement("pattern")
-	pattern.CreateAttr("id", id)
-	pattern.CreateAttr("x", "0")
-	pattern.CreateAttr("y", "0")
-	pattern.CreateAttr("width", fmt.Sprint(countryPatternSize))
-	pattern.CreateAttr("height", fmt.Sprint(countryPatternSize))
-	pattern.CreateAttr("patternUnits", "userSpaceOnUse")
-
-	g := pattern.CreateElement("g")
-	country := r.state.Countries[countryId]
-	style := occupationPatternStyle.With(
-		StyleOption{"stroke", getCountryMapColor(country.Flag.Colors[0], DefaultCountryFillColor)},
+	g := r.canvas.CreatePattern(id, countryPatternSize)
+	_, fillColor := r.countryMapColors(countryId, r.state.Countries[countryId])
+	style := r.styles.occupationPatternStyle.With(
+		StyleOption{"stroke", fillColor},
 	)
 	for x := 0.0; x < countryPatternSize; x += countryPatternStep {
-		r.createPath(g, style,
+		g.CreatePath(style,
 			NewPath().MoveTo(x+countryPatternStep, 0.0).LineTo(x, countryPatternSize))
 	}
 }
@@ -407,51 +448,77 @@ func (r *Renderer) renderCountryNames(countries []countryRenderContext) {
 	smallCountries := make(map[sgm.CountryId]int)
 
 	for _, ctx := range countries {
-		lines, maxLineLength := []string{ctx.country.Name()}, len(ctx.country.Name())
+		lines, maxLineLength := []string{ctx.name}, len(ctx.name)
 		rectW, _ := ctx.seg.bounds.Size()
-		if float64(maxLineLength)*countryFontSize > 0.8*rectW {
-			lines, maxLineLength = r.countryNameLines(ctx.country.Name())
+		if float64(maxLineLength)*r.styles.countryFontSize > 0.8*rectW {
+			lines, maxLineLength = r.countryNameLines(ctx.name)
+		}
+
+		playerName := r.playerName(ctx)
+		lineCount := len(lines)
+		if playerName != "" {
+			lineCount++
 		}
 
-		point, foundPoint := r.findCountryNamePoint(ctx.seg, maxLineLength, len(lines))
-		style := countryTextStyle
+		point, foundPoint := r.findCountryNamePoint(ctx.seg, maxLineLength, lineCount)
+		style := r.styles.countryTextStyle
 		if !foundPoint {
 			if index, hasIndex := smallCountries[ctx.seg.countryId]; hasIndex {
 				lines = []string{fmt.Sprint(index)}
 			} else {
 				index := len(smallCountryNames) + 1
 				smallCountries[ctx.seg.countryId] = index
-				smallCountryNames = append(smallCountryNames, ctx.country.Name())
+				name := ctx.name
+				if playerName != "" {
+					name = fmt.Sprintf("%s (%s)", name, playerName)
+				}
+				smallCountryNames = append(smallCountryNames, name)
 				lines = []string{fmt.Sprint(index)}
 			}
 
+			playerName = ""
 			point, foundPoint = r.findCountryNamePoint(ctx.seg, 2, 1)
 			if !foundPoint {
 				point = ctx.seg.bounds.Center()
 			}
 			style = style.With(
-				StyleOption{"font-size", fmt.Sprintf("%fpt", countryFontSize*0.75)},
+				StyleOption{"font-size", fmt.Sprintf("%fpt", r.styles.countryFontSize*0.75)},
 			)
 		}
 
-		point.Y += 1.2 * countryFontSize
+		point.Y += 1.2 * r.styles.countryFontSize
 		for _, line := range lines {
-			textEl := r.createText(r.canvas, style, point, line)
-			textEl.CreateAttr("text-anchor", "middle")
+			textEl := r.layer.CreateText(style.With(StyleOption{"text-anchor", "middle"}), point, line)
+			if r.opts.Interactive {
+				textEl.SetData("country-id", fmt.Sprint(ctx.seg.countryId))
+			}
 
-			point.Y += 1.2 * countryFontSize
+			point.Y += 1.2 * r.styles.countryFontSize
+		}
+		if playerName != "" {
+			point.Y -= 0.5 * r.styles.countryFontSize
+			r.layer.CreateText(r.styles.playerTextStyle.With(StyleOption{"text-anchor", "middle"}),
+				point, playerName)
 		}
 	}
 
 	legendPoint := sgmmath.Point{
 		X: r.innerBounds.Min.X,
-		Y: r.innerBounds.Max.Y - float64(len(smallCountries))*0.6*countryFontSize,
+		Y: r.innerBounds.Max.Y - float64(len(smallCountries))*0.6*r.styles.countryFontSize,
 	}
 	for idx, name := range smallCountryNames {
-		r.createText(r.canvas, countryLegendStyle, legendPoint,
+		r.layer.CreateText(r.styles.countryLegendStyle, legendPoint,
 			fmt.Sprintf("%d - %s", idx+1, name))
-		legendPoint.Y += 0.6 * countryFontSize
+		legendPoint.Y += 0.6 * r.styles.countryFontSize
+	}
+}
+
+func (r *Renderer) playerName(ctx countryRenderContext) string {
+	if !r.opts.ShowPlayerNames || ctx.country == nil || !ctx.country.IsHuman() ||
+		!r.isCountryKnown(ctx.seg.countryId) {
+		return ""
 	}
+	return ctx.country.Player.Name
 }
 
 func (r *Renderer) countryNameLines(name string) (lines []string, maxLineLength int) {
@@ -477,8 +544,8 @@ func (r *Renderer) findCountryNamePoint(seg *countrySegment, maxLineLength, line
 	center := seg.bounds.Center()
 	distance := math.Inf(+1)
 
-	requiredWidth := float64(maxLineLength) * countryFontSize
-	requiredHeight := float64(lineCount) * countryFontSize
+	requiredWidth := float64(maxLineLength) * r.styles.countryFontSize
+	requiredHeight := float64(lineCount) * r.styles.countryFontSize
 starLoop:
 	for starId := range seg.stars {
 		star := r.state.Stars[starId]
@@ -489,17 +556,17 @@ starLoop:
 			starPoint.X -= requiredWidth / 4
 		}
 		if star.IsSignificant() || len(star.Battles) > 0 {
-			starPoint.Y += fontSize + countryFontSize/2
+			starPoint.Y += r.styles.fontSize + r.styles.countryFontSize/2
 		}
 
 		rect := sgmmath.BoundingRect{
 			Min: sgmmath.Point{
 				X: starPoint.X - requiredWidth/2,
-				Y: starPoint.Y - countryFontSize/2,
+				Y: starPoint.Y - r.styles.countryFontSize/2,
 			},
 			Max: sgmmath.Point{
 				X: starPoint.X + requiredWidth/2,
-				Y: starPoint.Y + requiredHeight + countryFontSize/2,
+				Y: starPoint.Y + requiredHeight + r.styles.countryFontSize/2,
 			},
 		}
 
@@ -0,0 +1,316 @@
+package sgmrender
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/YashdalfTheGray/colorcode"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// Country color modes: flag colors as in game, flag colors replaced when
+// neighbors look alike, or palettes safe for color vision deficiencies
+const (
+	CountryColorsFlag         = "flag"
+	CountryColorsDistinct     = "distinct"
+	CountryColorsDeuteranopia = "deuteranopia"
+	CountryColorsProtanopia   = "protanopia"
+	CountryColorsTritanopia   = "tritanopia"
+)
+
+const (
+	// Minimum CIE76 distance between fill colors of neighbor countries
+	countryColorMinDistance = 25.0
+	// Lightness of border colors relative to fill colors
+	countryBorderLightness = 0.55
+)
+
+var (
+	// Okabe-Ito palette without black and gray
+	paletteRedGreenSafe = []string{
+		"#e69f00", "#56b4e9", "#009e73", "#f0e442", "#0072b2", "#d55e00", "#cc79a7",
+	}
+	// Paul Tol's muted palette without colors which tritanopes confuse with
+	// other ones, simulated colors are at least 20 units apart
+	paletteBlueYellowSafe = []string{
+		"#cc6677", "#332288", "#ddcc77", "#117733", "#88ccee", "#882255", "#999933",
+	}
+
+	countryColorPalettes = map[string][]string{
+		CountryColorsDeuteranopia: paletteRedGreenSafe,
+		CountryColorsProtanopia:   paletteRedGreenSafe,
+		CountryColorsTritanopia:   paletteBlueYellowSafe,
+	}
+
+	// Simulation of dichromacy in linear RGB by Machado et al. (2009)
+	cvdMatrices = map[string][3][3]float64{
+		CountryColorsProtanopia: {
+			{0.152286, 1.052583, -0.204868},
+			{0.114503, 0.786281, 0.099216},
+			{-0.003882, -0.048116, 1.051998},
+		},
+		CountryColorsDeuteranopia: {
+			{0.367322, 0.860646, -0.227968},
+			{0.280085, 0.672501, 0.047413},
+			{-0.011820, 0.042940, 0.968881},
+		},
+		CountryColorsTritanopia: {
+			{1.255528, -0.076749, -0.178779},
+			{-0.078411, 0.930809, 0.147602},
+			{0.004733, 0.691367, 0.303900},
+		},
+	}
+)
+
+func CountryColorModes() []string {
+	return []string{
+		CountryColorsFlag,
+		CountryColorsDistinct,
+		CountryColorsDeuteranopia,
+		CountryColorsProtanopia,
+		CountryColorsTritanopia,
+	}
+}
+
+func IsCountryColorMode(mode string) bool {
+	for _, knownMode := range CountryColorModes() {
+		if mode == knownMode {
+			return true
+		}
+	}
+	return false
+}
+
+type labColor [3]float64
+
+func (c labColor) distance(other labColor) float64 {
+	return math.Sqrt(
+		(c[0]-other[0])*(c[0]-other[0]) +
+			(c[1]-other[1])*(c[1]-other[1]) +
+			(c[2]-other[2])*(c[2]-other[2]))
+}
+
+// newLabColor converts sRGB color to CIELAB as seen by person with the
+// color vision deficiency if mode is one of the colorblind modes
+func newLabColor(hex string, mode string) labColor {
+	rgb, _ := colorcode.NewHexCode(hex).ToRGB()
+	linear := [3]float64{
+		srgbToLinear(float64(rgb.R) / 255),
+		srgbToLinear(float64(rgb.G) / 255),
+		srgbToLinear(float64(rgb.B) / 255),
+	}
+	if m, ok := cvdMatrices[mode]; ok {
+		var sim [3]float64
+		for i := range sim {
+			sim[i] = math.Max(0, math.Min(1,
+				m[i][0]*linear[0]+m[i][1]*linear[1]+m[i][2]*linear[2]))
+		}
+		linear = sim
+	}
+
+	// D65 white point
+	x := (0.4124*linear[0] + 0.3576*linear[1] + 0.1805*linear[2]) / 0.95047
+	y := 0.2126*linear[0] + 0.7152*linear[1] + 0.0722*linear[2]
+	z := (0.0193*linear[0] + 0.1192*linear[1] + 0.9505*linear[2]) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return labColor{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389.0 {
+		return math.Cbrt(t)
+	}
+	return t*24389.0/27.0/116.0 + 16.0/116.0
+}
+
+// darkenColor scales linear RGB components of the color, so hue is kept
+func darkenColor(hex string, factor float64) string {
+	rgb, _ := colorcode.NewHexCode(hex).ToRGB()
+	scale := func(c uint8) uint8 {
+		v := linearToSRGB(srgbToLinear(float64(c)/255) * factor)
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", scale(rgb.R), scale(rgb.G), scale(rgb.B))
+}
+
+type countryColorPair struct {
+	stroke, fill string
+}
+
+// countryNeighbors returns countries which territories touch each other,
+// i.e. Voronoi cells of their systems share an edge
+func (r *Renderer) countryNeighbors() map[sgm.CountryId]map[sgm.CountryId]struct{} {
+	cr := r.getCountryRenderer()
+	owner := func(cellStar *sgm.Star) sgm.CountryId {
+		if cellStar == nil || !r.isExplored(cellStar) || !r.isCountryKnown(cellStar.Owner()) {
+			return sgm.DefaultCountryId
+		}
+		return cellStar.Owner()
+	}
+
+	neighbors := make(map[sgm.CountryId]map[sgm.CountryId]struct{})
+	for _, edge := range cr.diagram.Edges {
+		if edge.LeftCell == nil || edge.RightCell == nil {
+			continue
+		}
+
+		_, leftStar := cr.starByCell(edge.LeftCell)
+		_, rightStar := cr.starByCell(edge.RightCell)
+		left, right := owner(leftStar), owner(rightStar)
+		if left == right || left == sgm.DefaultCountryId || right == sgm.DefaultCountryId {
+			continue
+		}
+		for _, pair := range [][2]sgm.CountryId{{left, right}, {right, left}} {
+			if neighbors[pair[0]] == nil {
+				neighbors[pair[0]] = make(map[sgm.CountryId]struct{})
+			}
+			neighbors[pair[0]][pair[1]] = struct{}{}
+		}
+	}
+	return neighbors
+}
+
+// computeCountryColors colors graph of neighbor countries greedily starting
+// with countries which have most neighbors, so each country gets a color
+// which is far enough from colors of its neighbors. Distinct mode keeps flag
+// colors when possible.
+func (r *Renderer) computeCountryColors(
+	mode string, neighbors map[sgm.CountryId]map[sgm.CountryId]struct{},
+) map[sgm.CountryId]countryColorPair {
+	countryIds := make([]sgm.CountryId, 0, len(r.state.Countries))
+	for countryId, country := range r.state.Countries {
+		if country != nil && !country.IsCrisis() {
+			countryIds = append(countryIds, countryId)
+		}
+	}
+	sort.Slice(countryIds, func(i, j int) bool {
+		ni, nj := len(neighbors[countryIds[i]]), len(neighbors[countryIds[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return countryIds[i] < countryIds[j]
+	})
+
+	palette := countryColorPalettes[mode]
+	if mode == CountryColorsDistinct {
+		palette = mapColorPalette()
+	}
+	paletteLab := make([]labColor, len(palette))
+	for i, color := range palette {
+		paletteLab[i] = newLabColor(color, mode)
+	}
+	usage := make([]int, len(palette))
+
+	colors := make(map[sgm.CountryId]countryColorPair)
+	labs := make(map[sgm.CountryId]labColor)
+	minNeighborDistance := func(countryId sgm.CountryId, lab labColor) float64 {
+		distance := math.Inf(1)
+		for neighborId := range neighbors[countryId] {
+			if neighborLab, ok := labs[neighborId]; ok {
+				distance = math.Min(distance, lab.distance(neighborLab))
+			}
+		}
+		return distance
+	}
+
+	for _, countryId := range countryIds {
+		country := r.state.Countries[countryId]
+		var flagLab *labColor
+		if mode == CountryColorsDistinct {
+			if stroke, fill, ok := getCountryFlagColors(country.Flag.Colors); ok {
+				lab := newLabColor(fill, mode)
+				if minNeighborDistance(countryId, lab) >= countryColorMinDistance {
+					colors[countryId] = countryColorPair{stroke: stroke, fill: fill}
+					labs[countryId] = lab
+					continue
+				}
+				flagLab = &lab
+			}
+		}
+
+		// Pick color among ones far enough from neighbors which is the
+		// closest to the flag color or the least used one. If there are no
+		// such colors, pick the farthest one.
+		isPreferred := func(i, j int) bool {
+			if flagLab != nil {
+				return paletteLab[i].distance(*flagLab) < paletteLab[j].distance(*flagLab)
+			}
+			return usage[i] < usage[j]
+		}
+		best, bestDistance := 0, -1.0
+		for i, lab := range paletteLab {
+			distance := math.Min(minNeighborDistance(countryId, lab), countryColorMinDistance)
+			if distance > bestDistance || (distance == bestDistance && isPreferred(i, best)) {
+				best, bestDistance = i, distance
+			}
+		}
+
+		usage[best]++
+		colors[countryId] = countryColorPair{
+			stroke: darkenColor(palette[best], countryBorderLightness),
+			fill:   palette[best],
+		}
+		labs[countryId] = paletteLab[best]
+	}
+	return colors
+}
+
+// mapColorPalette returns map colors of the game sorted by name, so
+// recolored countries still look like Stellaris countries
+func mapColorPalette() []string {
+	names := make([]string, 0, len(sgm.ColorMap.Colors))
+	for name := range sgm.ColorMap.Colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	palette := make([]string, 0, len(names))
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		color := getCountryMapColor(name, "")
+		if _, ok := seen[color]; !ok {
+			palette = append(palette, color)
+			seen[color] = struct{}{}
+		}
+	}
+	return palette
+}
+
+// recoloredCountryColors returns colors of the country in the current
+// color mode or false if flag colors should be used
+func (r *Renderer) recoloredCountryColors(countryId sgm.CountryId) (countryColorPair, bool) {
+	mode := r.opts.CountryColors
+	if mode == "" || mode == CountryColorsFlag {
+		return countryColorPair{}, false
+	}
+
+	if r.countryColors == nil {
+		if IsCountryColorMode(mode) {
+			r.countryColors = r.computeCountryColors(mode, r.countryNeighbors())
+		} else {
+			// Warn only once, all countries get flag colors
+			log.Printf("warn: unknown country color mode '%s', using flag colors", mode)
+			r.countryColors = make(map[sgm.CountryId]countryColorPair)
+		}
+	}
+	colors, ok := r.countryColors[countryId]
+	return colors, ok
+}
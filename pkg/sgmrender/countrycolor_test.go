@@ -0,0 +1,101 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+func TestComputeCountryColors(t *testing.T) {
+	// Countries 1, 2 and 3 form a triangle, country 4 only touches country 3
+	state := &sgm.GameState{Countries: make(map[sgm.CountryId]*sgm.Country)}
+	for countryId := sgm.CountryId(1); countryId <= 4; countryId++ {
+		country := &sgm.Country{}
+		country.Flag.Colors = []string{"red", "black"}
+		state.Countries[countryId] = country
+	}
+	neighbors := make(map[sgm.CountryId]map[sgm.CountryId]struct{})
+	for _, pair := range [][2]sgm.CountryId{{1, 2}, {2, 3}, {3, 1}, {3, 4}} {
+		for _, ids := range [][2]sgm.CountryId{pair, {pair[1], pair[0]}} {
+			if neighbors[ids[0]] == nil {
+				neighbors[ids[0]] = make(map[sgm.CountryId]struct{})
+			}
+			neighbors[ids[0]][ids[1]] = struct{}{}
+		}
+	}
+
+	r := &Renderer{state: state}
+	for _, mode := range []string{
+		CountryColorsDistinct,
+		CountryColorsDeuteranopia,
+		CountryColorsProtanopia,
+		CountryColorsTritanopia,
+	} {
+		colors := r.computeCountryColors(mode, neighbors)
+		if !assert.Len(t, colors, 4, mode) {
+			continue
+		}
+		for countryId, countryNeighbors := range neighbors {
+			for neighborId := range countryNeighbors {
+				lab := newLabColor(colors[countryId].fill, mode)
+				neighborLab := newLabColor(colors[neighborId].fill, mode)
+				assert.GreaterOrEqual(t, lab.distance(neighborLab), countryColorMinDistance,
+					"%s: %d (%s) and %d (%s)", mode, countryId, colors[countryId].fill,
+					neighborId, colors[neighborId].fill)
+			}
+		}
+	}
+}
+
+// TestCountryColorPalettes checks that colors of palettes are still
+// distinguishable with simulated color vision deficiency
+func TestCountryColorPalettes(t *testing.T) {
+	minDistances := map[string]float64{
+		CountryColorsDeuteranopia: 15,
+		CountryColorsProtanopia:   15,
+		CountryColorsTritanopia:   20,
+	}
+	for mode, palette := range countryColorPalettes {
+		for i := range palette {
+			for j := i + 1; j < len(palette); j++ {
+				distance := newLabColor(palette[i], mode).distance(newLabColor(palette[j], mode))
+				assert.GreaterOrEqual(t, distance, minDistances[mode],
+					"%s: %s and %s", mode, palette[i], palette[j])
+			}
+		}
+	}
+}
+
+func TestGetCountryFlagColors(t *testing.T) {
+	red := getCountryMapColor("red", "")
+	black := getCountryMapColor("black", "")
+	if !assert.NotEmpty(t, red) || !assert.NotEmpty(t, black) {
+		return
+	}
+
+	sgm.ColorMap.Colors["test_no_border"] = &sgm.ColorDef{
+		Map:              sgm.Color{RGB: []uint8{0, 0, 0}},
+		UseAsBorderColor: "no",
+	}
+	defer delete(sgm.ColorMap.Colors, "test_no_border")
+
+	for _, tc := range []struct {
+		flagColors   []string
+		stroke, fill string
+		ok           bool
+	}{
+		{[]string{"red", "black"}, black, red, true},
+		{[]string{"red"}, darkenColor(red, countryBorderLightness), red, true},
+		{[]string{"red", "unknown"}, darkenColor(red, countryBorderLightness), red, true},
+		{[]string{"red", "test_no_border"}, darkenColor(red, countryBorderLightness), red, true},
+		{[]string{"unknown", "black"}, "", "", false},
+		{nil, "", "", false},
+	} {
+		stroke, fill, ok := getCountryFlagColors(tc.flagColors)
+		assert.Equal(t, tc.ok, ok, "%v", tc.flagColors)
+		assert.Equal(t, tc.stroke, stroke, "%v", tc.flagColors)
+		assert.Equal(t, tc.fill, fill, "%v", tc.flagColors)
+	}
+}
@@ -0,0 +1,89 @@
+package sgmrender
+
+import (
+	"fmt"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	crisisAnimationDuration = "4s"
+)
+
+func (r *Renderer) renderCrisisSegment(
+	cr *countryRenderer, seg *countrySegment, country *sgm.Country,
+) countryRenderContext {
+	crisisType := country.CrisisType()
+	patternId := fmt.Sprintf("crisis-%s", crisisType)
+	if !r.canvas.HasDefinition(patternId) {
+		r.createCrisisPattern(crisisType, patternId)
+	}
+
+	style := r.styles.crisisCountryStyle.With(
+		StyleOption{"stroke", r.styles.crisisStrokeColors[crisisType]},
+		StyleOption{"fill", fmt.Sprintf("url(#%s)", patternId)},
+	)
+	pathEl := r.layer.CreatePath(style, cr.buildPath(seg))
+	r.annotateCountry(pathEl, seg, country.Name())
+	if !r.opts.NoAnimation {
+		// Crawling border makes crisis stand out even on busy late-game maps
+		pathEl.Animate(Animation{
+			Property: "stroke-dashoffset",
+			From:     "0",
+			To:       "8",
+			Duration: crisisAnimationDuration,
+		})
+	}
+
+	return countryRenderContext{
+		country: country,
+		name:    country.Name(),
+		seg:     seg,
+		style:   style,
+	}
+}
+
+func (r *Renderer) createCrisisPattern(crisisType sgm.CrisisType, id string) {
+	pattern := r.canvas.CreatePattern(id, countryPatternSize)
+
+	pattern.CreateRect(r.styles.backgroundStyle.With(
+		StyleOption{"fill", r.styles.crisisStrokeColors[crisisType]},
+		StyleOption{"fill-opacity", "0.6"},
+	), sgmmath.BoundingRect{
+		Max: sgmmath.Point{X: countryPatternSize, Y: countryPatternSize},
+	})
+
+	// Unlike occupation, crisis is hatched in both directions
+	g := pattern.CreateGroup(Style{})
+	style := r.styles.crisisPatternStyle.With(StyleOption{"stroke", r.styles.crisisFillColors[crisisType]})
+	for x := 0.0; x < countryPatternSize; x += countryPatternStep {
+		g.CreatePath(style,
+			NewPath().MoveTo(x+countryPatternStep, 0.0).LineTo(x, countryPatternSize))
+		g.CreatePath(style,
+			NewPath().MoveTo(x, 0.0).LineTo(x+countryPatternStep, countryPatternSize))
+	}
+}
+
+func (r *Renderer) renderCrisisStructures(ctx *starRenderContext) {
+	structures := ctx.star.CrisisStructures()
+	if len(structures) == 0 {
+		return
+	}
+	ctx.g = r.starLayerGroup(ctx, layerFleets)
+
+	// Portals and hubs are the targets for the rest of galaxy, so put them
+	// right on top of the system
+	point := sgmmath.Point{X: -r.styles.iconSizeMd / 2, Y: -r.styles.iconSizeMd / 2}
+	for _, structure := range structures {
+		g := ctx.g.CreateGroup(Style{})
+		if structure.Fleet != nil {
+			g.SetTitle(fmt.Sprintf("%s (%s)",
+				structure.Name(), structure.Fleet.MilitaryPowerString()))
+		} else {
+			g.SetTitle(structure.Name())
+		}
+		g.CreateIcon(point, "crisis-"+structure.Type.String(), r.styles.iconSizeMd)
+		point.X += r.styles.iconStepMd
+	}
+}
@@ -0,0 +1,72 @@
+package sgmrender
+
+import (
+	"log"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+const (
+	distanceMaxJumps        = 15
+	defaultDistanceRingStep = 5
+)
+
+func (r *Renderer) newDistanceOverlay() *MetricOverlay {
+	graph := sgmgraph.NewGraph(r.state, sgmgraph.Options{
+		UseWormholes:   r.opts.DistanceBypasses,
+		UseGateways:    r.opts.DistanceBypasses,
+		UseLGates:      r.opts.DistanceBypasses,
+		UseHyperRelays: r.opts.DistanceBypasses,
+	})
+
+	var sources []*sgm.Star
+	for _, starId := range r.opts.DistanceSources {
+		if star := r.state.Stars[starId]; star != nil {
+			sources = append(sources, star)
+		} else {
+			log.Printf("warn: source star #%d for distances is not found", starId)
+		}
+	}
+	if len(sources) == 0 {
+		sources = graph.Capitals()
+	}
+	r.distanceJumps = graph.JumpField(sources, distanceMaxJumps)
+
+	return &MetricOverlay{
+		Title: "Jumps",
+		Ramp:  RampDistance,
+		Min:   0,
+		Max:   distanceMaxJumps,
+		Steps: distanceMaxJumps + 1,
+		Metric: func(star *sgm.Star) (float64, bool) {
+			jumps, ok := r.distanceJumps[star]
+			return float64(jumps), ok
+		},
+	}
+}
+
+func (r *Renderer) renderDistanceRings() {
+	if !r.opts.ShowDistances {
+		return
+	}
+
+	ringStep := r.opts.DistanceRingStep
+	if ringStep <= 0 {
+		ringStep = defaultDistanceRingStep
+	}
+
+	cr := r.getCountryRenderer()
+	g := r.layer.CreateGroup(Style{})
+	for ringJumps := ringStep; ringJumps < distanceMaxJumps; ringJumps += ringStep {
+		rings := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+			if jumps, ok := r.distanceJumps[s]; ok && jumps <= ringJumps && r.isExplored(s) {
+				return sgm.CountryId(ringJumps), uint64(ringJumps)
+			}
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		})
+		for _, seg := range rings {
+			g.CreatePath(r.styles.distanceRingStyle, cr.buildPath(seg))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sgmrender
+
+import (
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+const (
+	unknownCountryName = "Unknown Empire"
+)
+
+// isExplored returns true if contents of the star system should be rendered
+// from the point of view of the selected country
+func (r *Renderer) isExplored(star *sgm.Star) bool {
+	return r.visibility == nil || r.visibility.IsExplored(star)
+}
+
+// isObserved returns true if fleets in the star system should be rendered
+func (r *Renderer) isObserved(star *sgm.Star) bool {
+	return r.visibility == nil || r.visibility.IsInSensorRange(star)
+}
+
+func (r *Renderer) isCountryKnown(countryId sgm.CountryId) bool {
+	return r.visibility == nil || r.visibility.KnowsCountry(countryId)
+}
+
+func (r *Renderer) countryName(countryId sgm.CountryId, country *sgm.Country) string {
+	if !r.isCountryKnown(countryId) {
+		return unknownCountryName
+	}
+	return sgm.CountryName(countryId, country)
+}
+
+// countryMapColors returns border and fill colors of the country on the map
+// or colors of its side if the map shows a war
+func (r *Renderer) countryMapColors(countryId sgm.CountryId, country *sgm.Country) (string, string) {
+	if country == nil || !r.isCountryKnown(countryId) {
+		return r.styles.countryBorderColor, r.styles.countryFillColor
+	}
+	if r.war != nil {
+		side := r.war.CountrySide(countryId)
+		return r.styles.warSideStrokeColors[side], r.styles.warSideFillColors[side]
+	}
+	if colors, ok := r.recoloredCountryColors(countryId); ok {
+		return colors.stroke, colors.fill
+	}
+	if stroke, fill, ok := getCountryFlagColors(country.Flag.Colors); ok {
+		return stroke, fill
+	}
+	return r.styles.countryBorderColor, r.styles.countryFillColor
+}
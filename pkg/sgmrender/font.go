@@ -0,0 +1,177 @@
+package sgmrender
+
+import (
+	"log"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/gofont/gosmallcaps"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// fontFace is one of the Go fonts which are used by backends that draw
+// text themselves instead of relying on fonts of the viewer
+type fontFace struct {
+	Name string
+	TTF  []byte
+
+	once sync.Once
+	font *sfnt.Font
+}
+
+var (
+	fontRegular    = &fontFace{Name: "GoRegular", TTF: goregular.TTF}
+	fontBold       = &fontFace{Name: "GoBold", TTF: gobold.TTF}
+	fontItalic     = &fontFace{Name: "GoItalic", TTF: goitalic.TTF}
+	fontBoldItalic = &fontFace{Name: "GoBoldItalic", TTF: gobolditalic.TTF}
+	fontSmallCaps  = &fontFace{Name: "GoSmallCaps", TTF: gosmallcaps.TTF}
+)
+
+// selectFontFace picks face matching font properties of the style, only
+// font-weight, font-style and font-variant are taken into account
+func selectFontFace(weight, fontStyle, variant string) *fontFace {
+	bold := weight == "bold" || weight == "bolder" ||
+		(len(weight) == 3 && weight >= "600" && weight <= "900")
+	italic := fontStyle == "italic" || fontStyle == "oblique"
+	switch {
+	case bold && italic:
+		return fontBoldItalic
+	case bold:
+		return fontBold
+	case italic:
+		return fontItalic
+	case variant == "small-caps" || variant == "petite-caps":
+		return fontSmallCaps
+	}
+	return fontRegular
+}
+
+func (f *fontFace) sfnt() *sfnt.Font {
+	f.once.Do(func() {
+		var err error
+		f.font, err = sfnt.Parse(f.TTF)
+		if err != nil {
+			log.Printf("error parsing font %s: %s", f.Name, err.Error())
+		}
+	})
+	return f.font
+}
+
+// glyphs returns indexes of glyphs for the text and their positions
+// relative to the origin of the text in units of font size
+func (f *fontFace) glyphs(buf *sfnt.Buffer, text string) (
+	indices []sfnt.GlyphIndex, offsets []float64, width float64,
+) {
+	sf := f.sfnt()
+	if sf == nil {
+		return
+	}
+	ppem := fixed.Int26_6(sf.UnitsPerEm()) << 6
+
+	var x fixed.Int26_6
+	for i, r := range []rune(text) {
+		idx, err := sf.GlyphIndex(buf, r)
+		if err != nil || idx == 0 {
+			idx, _ = sf.GlyphIndex(buf, '?')
+		}
+		if i > 0 {
+			kern, err := sf.Kern(buf, indices[i-1], idx, ppem, font.HintingNone)
+			if err == nil {
+				x += kern
+			}
+		}
+
+		indices = append(indices, idx)
+		offsets = append(offsets, float64(x)/float64(ppem))
+
+		advance, err := sf.GlyphAdvance(buf, idx, ppem, font.HintingNone)
+		if err == nil {
+			x += advance
+		}
+	}
+	return indices, offsets, float64(x) / float64(ppem)
+}
+
+// textOutline converts text into outline of its glyphs which can be filled
+// and stroked like any other shape
+func (f *fontFace) textOutline(text string, point sgmmath.Point, size float64, anchor string) outline {
+	sf := f.sfnt()
+	if sf == nil {
+		return nil
+	}
+
+	var buf sfnt.Buffer
+	indices, offsets, width := f.glyphs(&buf, text)
+	switch anchor {
+	case "middle":
+		point.X -= width * size / 2
+	case "end":
+		point.X -= width * size
+	}
+
+	upem := float64(sf.UnitsPerEm())
+	ppem := fixed.Int26_6(sf.UnitsPerEm()) << 6
+	scale := size / upem / 64
+
+	var o outline
+	for i, idx := range indices {
+		segments, err := sf.LoadGlyph(&buf, idx, ppem, nil)
+		if err != nil {
+			continue
+		}
+
+		origin := point.Add(sgmmath.Point{X: offsets[i] * size})
+		pt := func(p fixed.Point26_6) sgmmath.Point {
+			return origin.Add(sgmmath.Point{X: float64(p.X) * scale, Y: float64(p.Y) * scale})
+		}
+
+		var last sgmmath.Point
+		started := false
+		for _, seg := range segments {
+			switch seg.Op {
+			case sfnt.SegmentOpMoveTo:
+				if started {
+					o.close()
+				}
+				last = pt(seg.Args[0])
+				o.moveTo(last)
+				started = true
+			case sfnt.SegmentOpLineTo:
+				last = pt(seg.Args[0])
+				o.lineTo(last)
+			case sfnt.SegmentOpQuadTo:
+				end := pt(seg.Args[1])
+				o.quadTo(last, pt(seg.Args[0]), end)
+				last = end
+			case sfnt.SegmentOpCubeTo:
+				last = pt(seg.Args[2])
+				o.cubeTo(pt(seg.Args[0]), pt(seg.Args[1]), last)
+			}
+		}
+		if started {
+			o.close()
+		}
+	}
+	return o
+}
+
+// advance returns advance width of the glyph in units of font size
+func (f *fontFace) advance(buf *sfnt.Buffer, idx sfnt.GlyphIndex) float64 {
+	sf := f.sfnt()
+	if sf == nil {
+		return 0
+	}
+	ppem := fixed.Int26_6(sf.UnitsPerEm()) << 6
+	advance, err := sf.GlyphAdvance(buf, idx, ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(advance) / float64(ppem)
+}
@@ -0,0 +1,225 @@
+package sgmrender
+
+import (
+	"sort"
+
+	"github.com/pzsz/voronoi"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// FrontLine is a chain of Voronoi edges where systems controlled by attackers
+// of the war touch systems controlled by defenders. Occupied systems belong
+// to the side of the occupier.
+type FrontLine struct {
+	Points []sgmmath.Point
+	// Front line encloses territory of one of sides
+	Closed bool
+	// Territory of the side enclosed by the front line consists only of
+	// occupied systems
+	Pocket bool
+
+	// Systems on both sides of the front line
+	AttackerStarIds []sgm.StarId
+	DefenderStarIds []sgm.StarId
+
+	startKey, endKey countryBorderKey
+}
+
+// Length returns length of the front line in map units
+func (front *FrontLine) Length() (length float64) {
+	for i := 1; i < len(front.Points); i++ {
+		length += front.Points[i-1].Distance(front.Points[i])
+	}
+	return
+}
+
+func (front *FrontLine) addStarIds(attackerStarId, defenderStarId sgm.StarId) {
+	front.AttackerStarIds = appendStarId(front.AttackerStarIds, attackerStarId)
+	front.DefenderStarIds = appendStarId(front.DefenderStarIds, defenderStarId)
+}
+
+func appendStarId(starIds []sgm.StarId, starId sgm.StarId) []sgm.StarId {
+	for _, id := range starIds {
+		if id == starId {
+			return starIds
+		}
+	}
+	return append(starIds, starId)
+}
+
+// FrontLines extracts front lines of the war from borders of territories
+// controlled by attackers, so every front line is traced exactly once
+func (r *Renderer) FrontLines(war *sgm.War) (fronts []*FrontLine) {
+	cr := r.getCountryRenderer()
+	segments := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		if !r.isExplored(s) {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+		side := war.StarSide(s)
+		if side == sgm.WarSideNone {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+		return sgm.CountryId(side), uint64(side)
+	})
+
+	for _, seg := range segments {
+		if sgm.WarSide(seg.countryId) != sgm.WarSideAttacker {
+			continue
+		}
+		for _, border := range seg.borders {
+			fronts = append(fronts, cr.buildFrontLines(war, border)...)
+		}
+	}
+
+	for _, front := range fronts {
+		sort.Slice(front.AttackerStarIds, func(i, j int) bool {
+			return front.AttackerStarIds[i] < front.AttackerStarIds[j]
+		})
+		sort.Slice(front.DefenderStarIds, func(i, j int) bool {
+			return front.DefenderStarIds[i] < front.DefenderStarIds[j]
+		})
+		front.Pocket = front.Closed && r.isPocket(cr, war, front)
+	}
+	return
+}
+
+// buildFrontLines splits border of attackers territory into continuous runs
+// of edges shared with defenders
+func (cr *countryRenderer) buildFrontLines(war *sgm.War, border *countryBorder) []*FrontLine {
+	var fronts []*FrontLine
+	var front *FrontLine
+	for _, he := range border.edges {
+		otherCell := he.Edge.GetOtherCell(he.Cell)
+		if otherCell == nil {
+			front = nil
+			continue
+		}
+		otherStarId, otherStar := cr.starByCell(otherCell)
+		if !cr.r.isExplored(otherStar) || war.StarSide(otherStar) != sgm.WarSideDefender {
+			front = nil
+			continue
+		}
+
+		startVertex, endVertex := he.Edge.Va.Vertex, he.Edge.Vb.Vertex
+		if he.Edge.RightCell == he.Cell {
+			startVertex, endVertex = endVertex, startVertex
+		}
+		startKey, endKey := borderEdgeKey(startVertex), borderEdgeKey(endVertex)
+		startPoint, endPoint := cr.buildEdge(he, 0.0)
+
+		if front == nil || front.endKey != startKey {
+			front = &FrontLine{
+				Points:   []sgmmath.Point{startPoint},
+				startKey: startKey,
+			}
+			fronts = append(fronts, front)
+		}
+		front.Points = append(front.Points, endPoint)
+		front.endKey = endKey
+
+		starId, _ := cr.starByCell(he.Cell)
+		front.addStarIds(starId, otherStarId)
+	}
+	if len(fronts) == 0 {
+		return nil
+	}
+
+	// Border is a loop, so the last run may continue into the first one
+	first, last := fronts[0], fronts[len(fronts)-1]
+	if last.endKey == first.startKey {
+		if first == last {
+			first.Closed = true
+		} else {
+			last.Points = append(last.Points, first.Points[1:]...)
+			last.endKey = first.endKey
+			for _, starId := range first.AttackerStarIds {
+				last.AttackerStarIds = appendStarId(last.AttackerStarIds, starId)
+			}
+			for _, starId := range first.DefenderStarIds {
+				last.DefenderStarIds = appendStarId(last.DefenderStarIds, starId)
+			}
+			fronts = fronts[1:]
+		}
+	}
+	return fronts
+}
+
+// isPocket returns true if all systems of the side enclosed by the closed
+// front line are occupied. Enclosed side is the one which systems along the
+// front line are inside of it.
+func (r *Renderer) isPocket(cr *countryRenderer, war *sgm.War, front *FrontLine) bool {
+	side, starIds := sgm.WarSideDefender, front.DefenderStarIds
+	if len(front.AttackerStarIds) > 0 &&
+		polygonContains(front.Points, r.state.Stars[front.AttackerStarIds[0]].Point()) {
+		side, starIds = sgm.WarSideAttacker, front.AttackerStarIds
+	}
+
+	cells := make(map[sgm.StarId]*voronoi.Cell, len(starIds))
+	for _, cell := range cr.diagram.Cells {
+		cells[cr.starMap[cell.Site]] = cell
+	}
+
+	// Flood fill cells of the side, the front line is the border which
+	// stops it
+	queue := make([]*voronoi.Cell, 0, len(starIds))
+	visited := make(map[*voronoi.Cell]struct{})
+	for _, starId := range starIds {
+		if cell := cells[starId]; cell != nil {
+			queue = append(queue, cell)
+			visited[cell] = struct{}{}
+		}
+	}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+
+		_, star := cr.starByCell(cell)
+		if star.Occupier() == sgm.DefaultCountryId {
+			return false
+		}
+		for _, he := range cell.Halfedges {
+			neighCell := he.Edge.GetOtherCell(cell)
+			if neighCell == nil {
+				continue
+			}
+			if _, ok := visited[neighCell]; ok {
+				continue
+			}
+
+			_, neighStar := cr.starByCell(neighCell)
+			if !r.isExplored(neighStar) || war.StarSide(neighStar) != side ||
+				neighStar.IsDistant() != star.IsDistant() {
+				continue
+			}
+			visited[neighCell] = struct{}{}
+			queue = append(queue, neighCell)
+		}
+	}
+	return len(visited) > 0
+}
+
+// polygonContains checks if point is inside of polygon using ray casting
+func polygonContains(points []sgmmath.Point, point sgmmath.Point) bool {
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		pi, pj := points[i], points[j]
+		if (pi.Y > point.Y) != (pj.Y > point.Y) &&
+			point.X < (pj.X-pi.X)*(point.Y-pi.Y)/(pj.Y-pi.Y)+pi.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (front *FrontLine) path() Path {
+	path := NewPath().MoveToPoint(front.Points[0])
+	for _, point := range front.Points[1:] {
+		path = path.LineToPoint(point)
+	}
+	if front.Closed {
+		path = path.Complete()
+	}
+	return path
+}
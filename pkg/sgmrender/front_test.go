@@ -0,0 +1,92 @@
+package sgmrender
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+const frontTestGridSize = 7
+
+// newFrontTestState creates grid of systems of one side of the war with the
+// system of another side in the center surrounded by ring of systems
+// occupied by it. Country 1 is attacker, country 0 is defender.
+func newFrontTestState(ownerId, enclaveId sgm.CountryId) (*sgm.GameState, *sgm.War) {
+	war := &sgm.War{
+		Attackers: []sgm.WarCountry{{CountryId: 1}},
+		Defenders: []sgm.WarCountry{{CountryId: 0}},
+	}
+	state := &sgm.GameState{
+		Stars: make(map[sgm.StarId]*sgm.Star),
+		Countries: map[sgm.CountryId]*sgm.Country{
+			0: {},
+			1: {},
+		},
+		Wars: map[sgm.WarId]*sgm.War{0: war},
+	}
+
+	center := frontTestGridSize / 2
+	for y := 0; y < frontTestGridSize; y++ {
+		for x := 0; x < frontTestGridSize; x++ {
+			star := &sgm.Star{Sector: &sgm.Sector{Owner: ownerId}}
+			// Jitter coordinates, so Voronoi vertices are not degenerate
+			star.Coordinate.X = float64(x*20) + 5*math.Sin(float64(x*13+y*7))
+			star.Coordinate.Y = float64(y*20) + 5*math.Cos(float64(x*5+y*11))
+
+			dx, dy := x-center, y-center
+			switch {
+			case dx == 0 && dy == 0:
+				star.Sector.Owner = enclaveId
+			case dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1:
+				occupyStar(star, enclaveId)
+			}
+			state.Stars[sgm.StarId(y*frontTestGridSize+x)] = star
+		}
+	}
+	return state, war
+}
+
+func occupyStar(star *sgm.Star, occupierId sgm.CountryId) {
+	star.Starbases = []*sgm.Starbase{{
+		Owner: star.Owner(),
+		Station: &sgm.Ship{Fleet: &sgm.Fleet{
+			OwnershipStatus: sgm.FleetOwnershipLostControl,
+			DebtorId:        occupierId,
+		}},
+	}}
+}
+
+func TestFrontLinesPocket(t *testing.T) {
+	centerId := sgm.StarId(frontTestGridSize * frontTestGridSize / 2)
+	for _, tc := range []struct {
+		name               string
+		ownerId, enclaveId sgm.CountryId
+		occupiedCenter     bool
+		pocket             bool
+	}{
+		{"attacker enclave", 0, 1, false, false},
+		{"attacker pocket", 0, 1, true, true},
+		{"defender enclave", 1, 0, false, false},
+		{"defender pocket", 1, 0, true, true},
+	} {
+		state, war := newFrontTestState(tc.ownerId, tc.enclaveId)
+		if tc.occupiedCenter {
+			star := state.Stars[centerId]
+			star.Sector.Owner = tc.ownerId
+			occupyStar(star, tc.enclaveId)
+		}
+
+		r := NewRenderer(state, RenderOptions{})
+		fronts := r.FrontLines(war)
+		if !assert.Len(t, fronts, 1, tc.name) {
+			continue
+		}
+		assert.True(t, fronts[0].Closed, tc.name)
+		assert.NotContains(t, fronts[0].AttackerStarIds, centerId, tc.name)
+		assert.NotContains(t, fronts[0].DefenderStarIds, centerId, tc.name)
+		assert.Equal(t, tc.pocket, fronts[0].Pocket, tc.name)
+	}
+}
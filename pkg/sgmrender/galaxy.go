@@ -7,8 +7,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/beevik/etree"
-
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
 )
@@ -22,7 +20,9 @@ type starRenderContext struct {
 	starId sgm.StarId
 	star   *sgm.Star
 
-	g                               *etree.Element
+	g                               Group
+	layerGroups                     map[string]Group
+	title                           string
 	iconOffset                      float64
 	nameOffsetTop, nameOffsetBottom float64
 	quadrant                        int
@@ -37,19 +37,28 @@ func (r *Renderer) renderStars() (stars []*starRenderContext) {
 		ctx.quadrant = r.pickTextQuadrant(ctx.starId)
 
 		p := star.Point()
-		ctx.g = r.canvas.CreateElement("g")
-		ctx.g.CreateAttr("transform", fmt.Sprintf("translate(%f, %f)", p.X, p.Y))
+		ctx.g = r.layer.CreateTranslatedGroup(p)
+		r.annotateStar(ctx)
 		if r.opts.NoStarSystems {
-			r.createPath(ctx.g, defaultStarStyle, defaultStarPath)
+			ctx.g.CreatePath(r.styles.defaultStarStyle, defaultStarPath)
+			continue
+		}
+		if !r.isExplored(star) {
+			ctx.g.CreatePath(r.styles.unknownStarStyle, defaultStarPath)
 			continue
 		}
 
-		ctx.battleYear, ctx.battleRef = r.findBattle(star)
+		if !r.opts.BattleHistory {
+			ctx.battleYear, ctx.battleRef = r.findBattle(star)
+		}
+		hasVisitors := (!r.opts.NoMonsters && len(star.MonsterFleets()) > 0) ||
+			len(star.CrisisStructures()) > 0
 
 		// Render starbase if one exists, otherwise render
-		if (star.PrimaryStarbase() == nil || !star.IsSignificant()) && ctx.battleYear == 0 {
+		if (star.PrimaryStarbase() == nil || !star.IsSignificant()) &&
+			ctx.battleYear == 0 && !hasVisitors {
 			if !r.opts.NoInsignificantStars {
-				r.createPath(ctx.g, defaultStarStyle, defaultStarPath)
+				ctx.g.CreatePath(r.styles.defaultStarStyle, defaultStarPath)
 			}
 			continue
 		}
@@ -60,43 +69,49 @@ func (r *Renderer) renderStars() (stars []*starRenderContext) {
 }
 
 func (r *Renderer) renderStarbase(ctx *starRenderContext) {
+	r.setDetailLevel(DetailStars)
+	ctx.g = r.starLayerGroup(ctx, layerStarbases)
 	starbase := ctx.star.PrimaryStarbase()
 	if starbase == nil {
+		// Unclaimed system which is significant only due to its visitors
+		ctx.g.CreatePath(r.styles.defaultStarStyle, defaultStarPath)
+		ctx.iconOffset = starHalfSize
 		return
 	}
 
 	lostControl := ctx.star.Occupier() != sgm.DefaultCountryId
 	if starbase.Level == sgm.StarbaseOutpost {
-		style := outpostStyle
+		style := r.styles.outpostStyle
 		if lostControl {
-			style = outpostLostStyle
+			style = r.styles.outpostLostStyle
 		}
 
-		r.createPath(ctx.g, style, outpostPath)
+		ctx.g.CreatePath(style, outpostPath)
 		ctx.iconOffset = outpostHalfSize
 	} else {
-		baseStyle := baseStarbaseStyle
+		baseStyle := r.styles.baseStarbaseStyle
 		if lostControl {
-			baseStyle = starbaseLostStyle
+			baseStyle = r.styles.starbaseLostStyle
 		}
 
 		style := baseStyle
-		starbaseStroke := starbaseStrokes[starbase.Level]
+		starbaseStroke := r.styles.starbaseStrokes[starbase.Level]
 		if starbaseStroke > 0.0 {
 			style = style.With(StyleOption{"stroke-width", fmt.Sprintf("%fpt", starbaseStroke)})
 		}
-		r.createPath(ctx.g, style, starbasePath)
+		ctx.g.CreatePath(style, starbasePath)
 		ctx.iconOffset = starbaseHalfSize + starbaseStroke/2
 
 		if starbase.Level == sgm.StarbaseCitadel {
-			r.createPath(ctx.g, baseStyle, citadelInnerPath)
+			ctx.g.CreatePath(baseStyle, citadelInnerPath)
 		}
 
 		if !lostControl {
 			role := starbase.Role()
 			if role != sgm.StarbaseRoleMax {
+				r.setDetailLevel(DetailSystems)
 				rolePoint := sgmmath.Point{X: -ctx.iconOffset / 2, Y: -ctx.iconOffset / 3}
-				r.createIcon(ctx.g, rolePoint, "starbase-"+role.String(), iconSizeSm)
+				ctx.g.CreateIcon(rolePoint, "starbase-"+role.String(), r.styles.iconSizeSm)
 			}
 		}
 	}
@@ -104,10 +119,15 @@ func (r *Renderer) renderStarbase(ctx *starRenderContext) {
 
 func (r *Renderer) renderStarFeatures(ctx *starRenderContext) {
 	// Fleets
+	r.setDetailLevel(DetailStars)
 	r.renderAllFleets(ctx)
+	r.renderMonsters(ctx)
+	r.renderCrisisStructures(ctx)
 	r.renderBattle(ctx)
 
 	// Other features
+	r.setDetailLevel(DetailSystems)
+	ctx.g = r.starLayerGroup(ctx, layerStarbases)
 	megastructures := ctx.star.MegastructuresBySize(sgm.MegastructureSizeStar)
 	ringWorlds := ctx.star.MegastructuresBySize(sgm.MegastructureSizeRingWorld)
 	if len(ringWorlds) > 0 {
@@ -121,11 +141,11 @@ func (r *Renderer) renderStarFeatures(ctx *starRenderContext) {
 
 	planetPoint := sgmmath.Point{X: -2 * ctx.iconOffset / 3, Y: -ctx.iconOffset}
 	for _, ms := range megastructures {
-		planetPoint.X -= iconStepMd
-		r.renderMegastructure(ctx, planetPoint, sgm.MegastructureSizeStar, ms, iconSizeMd)
+		planetPoint.X -= r.styles.iconStepMd
+		r.renderMegastructure(ctx, planetPoint, sgm.MegastructureSizeStar, ms, r.styles.iconSizeMd)
 	}
 	for _, planet := range colonies {
-		planetPoint.X -= iconStepMd
+		planetPoint.X -= r.styles.iconStepMd
 		r.renderPlanet(ctx, planetPoint, planet)
 	}
 
@@ -140,34 +160,34 @@ func (r *Renderer) renderStarFeatures(ctx *starRenderContext) {
 	}
 	for i, ms := range planetStations {
 		if ms != nil {
-			r.renderMegastructure(ctx, stationPoint, sgm.MegastructureSizePlanet, ms, iconSizeSm)
+			r.renderMegastructure(ctx, stationPoint, sgm.MegastructureSizePlanet, ms, r.styles.iconSizeSm)
 		} else {
-			r.createIcon(ctx.g, stationPoint, "habitat", iconSizeSm)
+			ctx.g.CreateIcon(stationPoint, "habitat", r.styles.iconSizeSm)
 		}
 
 		if (i+1)%planetStationsStep == 0 {
 			stationPoint.X = 2 * ctx.iconOffset / 3
-			stationPoint.Y += iconStepSm
+			stationPoint.Y += r.styles.iconStepSm
 		} else {
-			stationPoint.X += iconStepSm
+			stationPoint.X += r.styles.iconStepSm
 		}
 	}
 
 	// Bypasses
 	bypasses := ctx.star.Bypasses()
-	transportPoint := sgmmath.Point{X: -ctx.iconOffset / 2, Y: ctx.iconOffset - iconStepMd}
+	transportPoint := sgmmath.Point{X: -ctx.iconOffset / 2, Y: ctx.iconOffset - r.styles.iconStepMd}
 	if ctx.iconOffset == outpostHalfSize && (len(colonies)+len(megastructures)) > 0 {
-		transportPoint.Y += iconStepMd / 2
-		ctx.nameOffsetBottom += iconStepMd / 2
+		transportPoint.Y += r.styles.iconStepMd / 2
+		ctx.nameOffsetBottom += r.styles.iconStepMd / 2
 	}
 	for _, bypass := range bypasses {
-		transportPoint.X -= iconStepSm
-		r.createIcon(ctx.g, transportPoint, "bypass-"+bypass, iconSizeSm)
+		transportPoint.X -= r.styles.iconStepSm
+		ctx.g.CreateIcon(transportPoint, "bypass-"+bypass, r.styles.iconSizeSm)
 	}
 }
 
 func (r *Renderer) renderAllFleets(ctx *starRenderContext) {
-	if r.opts.NoFleets {
+	if r.opts.NoFleets || !r.isObserved(ctx.star) {
 		return
 	}
 
@@ -187,6 +207,7 @@ func (r *Renderer) renderFleets(ctx *starRenderContext, role sgm.WarRole, fleets
 	if len(fleets) == 0 {
 		return
 	}
+	ctx.g = r.starLayerGroup(ctx, layerFleets)
 
 	fleetPoint := sgmmath.Point{
 		Y: -ctx.iconOffset - fleetHalfSize/2,
@@ -219,8 +240,8 @@ func (r *Renderer) renderFleets(ctx *starRenderContext, role sgm.WarRole, fleets
 		fleetPoint.X += step
 	}
 	if extraFleets > 0 {
-		r.createText(ctx.g, fleetTextStyle,
-			fleetPoint.Add(sgmmath.Point{-fleetStep, fleetHalfSize}),
+		ctx.g.CreateText(r.styles.fleetTextStyle,
+			fleetPoint.Add(sgmmath.Point{X: -fleetStep, Y: fleetHalfSize}),
 			fmt.Sprintf("+%d", extraFleets))
 	}
 }
@@ -232,26 +253,65 @@ func (r *Renderer) renderFleet(
 	if fleetStrength <= 0.2 {
 		fleetStrength = 0.2
 	}
-	style := fleetStyles[role].With(StyleOption{"stroke-width", fmt.Sprintf("%fpt", fleetStrength)})
+	style := r.styles.fleetStyles[role].With(StyleOption{"stroke-width", fmt.Sprintf("%fpt", fleetStrength)})
 
 	fleetPath := newFleetPath(fleetHalfSize+fleetStrength, fleetStrength/2)
 	fleetPath.Translate(point)
-	pathEl := r.createPath(ctx.g, style, fleetPath)
-	r.createTitle(pathEl, fmt.Sprintf("%s (%s)", fleet.Name(), fleet.MilitaryPowerString()))
+	pathEl := ctx.g.CreatePath(style, fleetPath)
+	pathEl.SetTitle(fmt.Sprintf("%s (%s)", fleet.Name(), fleet.MilitaryPowerString()))
 
-	if fleet.Owner != nil {
+	if fleet.Owner != nil && r.isCountryKnown(fleet.OwnerId) {
 		bgColor := sgm.ColorMap.Colors[fleet.Owner.Flag.Colors[0]]
 		fgColor := sgm.ColorMap.Colors[fleet.Owner.Flag.Colors[1]]
 		if bgColor != nil && fgColor != nil {
-			fleetIdentStyle := fleetIdentStyle.With(
+			fleetIdentStyle := r.styles.fleetIdentStyle.With(
 				StyleOption{"stroke", fgColor.Ship.Color().ToHexCode().String()},
 				StyleOption{"fill", bgColor.Ship.Color().ToHexCode().String()},
 			)
 
 			fleetIdentPath := newDiamondPath(2 * fleetHalfSize / 3)
 			fleetIdentPath.Translate(point)
-			r.createPath(ctx.g, fleetIdentStyle, fleetIdentPath)
+			ctx.g.CreatePath(fleetIdentStyle, fleetIdentPath)
+		}
+	}
+}
+
+func (r *Renderer) renderMonsters(ctx *starRenderContext) {
+	if r.opts.NoMonsters || !r.isObserved(ctx.star) {
+		return
+	}
+
+	monsters := ctx.star.MonsterFleets()
+	if len(monsters) == 0 {
+		return
+	}
+	ctx.g = r.starLayerGroup(ctx, layerFleets)
+
+	if ctx.star.IsGuarded() {
+		ctx.g.CreateCircle(r.styles.guardedStarStyle, sgmmath.Point{}, ctx.iconOffset+fleetHalfSize)
+	}
+
+	// Monsters are shown in the bottom-right corner opposite to bypasses,
+	// one icon per class of monsters, colored by the most dangerous fleet
+	monsterPoint := sgmmath.Point{X: ctx.iconOffset / 2, Y: ctx.iconOffset - r.styles.iconStepMd}
+	renderedClasses := make(map[sgm.CountryClass]struct{})
+	for _, fleet := range monsters {
+		class := fleet.Owner.Class()
+		if _, isRendered := renderedClasses[class]; isRendered {
+			continue
 		}
+		renderedClasses[class] = struct{}{}
+
+		radius := r.styles.iconSizeSm/2 + 0.2
+		style := r.styles.monsterRingStyle.With(
+			StyleOption{"stroke", r.styles.monsterDangerColors[fleet.DangerLevel()]})
+		circleEl := ctx.g.CreateCircle(style,
+			monsterPoint.Add(sgmmath.Point{X: r.styles.iconSizeSm / 2, Y: r.styles.iconSizeSm / 2}), radius)
+		circleEl.SetTitle(fmt.Sprintf("%s (%s, %s danger)",
+			fleet.Name(), fleet.MilitaryPowerString(), fleet.DangerLevel()))
+
+		ctx.g.CreateIcon(monsterPoint, "monster-"+class.String(), r.styles.iconSizeSm)
+		monsterPoint.X += r.styles.iconStepSm + 0.4
 	}
 }
 
@@ -275,13 +335,13 @@ func (r *Renderer) renderMegastructure(
 	}
 
 	for _, icon := range icons {
-		r.createIcon(ctx.g, point, icon, iconSize)
+		ctx.g.CreateIcon(point, icon, iconSize)
 	}
 }
 
 func (r *Renderer) renderPlanet(ctx *starRenderContext, point sgmmath.Point, planet *sgm.Planet) {
-	radius := float64(iconSizeMd) / 2
-	center := point.Add(sgmmath.Point{radius, radius})
+	radius := float64(r.styles.iconSizeMd) / 2
+	center := point.Add(sgmmath.Point{X: radius, Y: radius})
 	ringRadius := radius
 
 	strokeWidth := 0.4
@@ -297,27 +357,25 @@ func (r *Renderer) renderPlanet(ctx *starRenderContext, point sgmmath.Point, pla
 		radius -= 0.1
 	}
 
-	style := basePlanetStyle.With(
+	style := r.styles.basePlanetStyle.With(
 		StyleOption{"stroke-width", fmt.Sprintf("%.1fpt", strokeWidth)},
 	)
 	title := fmt.Sprintf("%s (%d pops)", planet.Name(), planet.EmployablePops)
-	r.createTitle(r.createCircle(ctx.g, style, center, radius), title)
+	ctx.g.CreateCircle(style, center, radius).SetTitle(title)
 	if planet.EmployablePops > 75 {
-		r.createTitle(r.createCircle(ctx.g, basePlanetStyle, center, radius/2), title)
+		ctx.g.CreateCircle(r.styles.basePlanetStyle, center, radius/2).SetTitle(title)
 	}
 
 	if planet.Designation == sgm.PlanetDesignationCapital {
-		r.createIcon(ctx.g, point, "colony-capital", iconSizeMd)
+		ctx.g.CreateIcon(point, "colony-capital", r.styles.iconSizeMd)
 	}
 	if planet.Class == sgm.PlanetClassEcumenopolis {
-		r.createIcon(ctx.g, point, "colony-ecumenopolis", iconSizeMd)
+		ctx.g.CreateIcon(point, "colony-ecumenopolis", r.styles.iconSizeMd)
 	}
 
 	if planet.OrbitalStarbase() != nil {
-		g := ctx.g.CreateElement("g")
-		g.CreateAttr("transform",
-			fmt.Sprintf("translate(%f, %f)", center.X, center.Y))
-		r.createPath(g, planetRingStyle, newOrbitalRingPath(ringRadius))
+		g := ctx.g.CreateTranslatedGroup(center)
+		g.CreatePath(r.styles.planetRingStyle, newOrbitalRingPath(ringRadius))
 	}
 }
 
@@ -368,14 +426,15 @@ func (r *Renderer) renderBattle(ctx *starRenderContext) {
 
 	battlePoint := sgmmath.Point{X: -ctx.iconOffset / 2}
 	if ctx.quadrant >= 0 {
-		battlePoint.Y = -ctx.iconOffset - ctx.nameOffsetTop - fontSize - iconSizeSm
+		battlePoint.Y = -ctx.iconOffset - ctx.nameOffsetTop - r.styles.fontSize - r.styles.iconSizeSm
 	} else {
-		battlePoint.Y = ctx.iconOffset + ctx.nameOffsetBottom + fontSize
+		battlePoint.Y = ctx.iconOffset + ctx.nameOffsetBottom + r.styles.fontSize
 	}
 
-	r.createIcon(ctx.g, battlePoint, "battle-"+winnerIcon+"-won", iconSizeSm)
-	r.renderStarText(ctx, battleTextStyle, sgmmath.Point{iconSizeSm, 1.5 * fontSize},
-		fmt.Sprint(ctx.battleYear))
+	ctx.g = r.starLayerGroup(ctx, layerBattles)
+	ctx.g.CreateIcon(battlePoint, "battle-"+winnerIcon+"-won", r.styles.iconSizeSm)
+	r.renderStarText(ctx, r.layers[layerBattles], r.styles.battleTextStyle,
+		sgmmath.Point{X: r.styles.iconSizeSm, Y: 1.5 * r.styles.fontSize}, fmt.Sprint(ctx.battleYear))
 }
 
 func (r *Renderer) renderStarName(ctx *starRenderContext) {
@@ -384,11 +443,11 @@ func (r *Renderer) renderStarName(ctx *starRenderContext) {
 		name = strings.ReplaceAll(name[5:], "_", " ")
 	}
 
-	r.renderStarText(ctx, starTextStyle, sgmmath.Point{}, name)
+	r.renderStarText(ctx, r.layer, r.styles.starTextStyle, sgmmath.Point{}, name)
 }
 
 func (r *Renderer) renderStarText(
-	ctx *starRenderContext, style Style, off sgmmath.Point, text string,
+	ctx *starRenderContext, g Group, style Style, off sgmmath.Point, text string,
 ) {
 	var textAnchor string
 	point := ctx.star.Point()
@@ -401,13 +460,12 @@ func (r *Renderer) renderStarText(
 		point.X += 2*ctx.iconOffset/3 - off.X
 	}
 	if ctx.quadrant >= 0 {
-		point.Y -= ctx.iconOffset/2 + 2*fontSize/3 + ctx.nameOffsetTop + off.Y
+		point.Y -= ctx.iconOffset/2 + 2*r.styles.fontSize/3 + ctx.nameOffsetTop + off.Y
 	} else {
-		point.Y += ctx.iconOffset + fontSize + ctx.nameOffsetBottom + off.Y
+		point.Y += ctx.iconOffset + r.styles.fontSize + ctx.nameOffsetBottom + off.Y
 	}
 
-	textEl := r.createText(r.canvas, style, point, text)
-	textEl.CreateAttr("text-anchor", textAnchor)
+	g.CreateText(style.With(StyleOption{"text-anchor", textAnchor}), point, text)
 }
 
 func (r *Renderer) renderHyperlanes() {
@@ -423,17 +481,27 @@ func (r *Renderer) renderHyperlanes() {
 			if _, isRendered := renderedStars[hyperlane.ToId]; isRendered {
 				continue
 			}
+			if !r.isExplored(star) && !r.isExplored(hyperlane.To) {
+				continue
+			}
 
-			style := hyperlaneStyle
+			style := r.styles.hyperlaneStyle
 			if hasRelay && hyperlane.To.HasHyperRelay() {
 				style = style.With(StyleOption{"stroke-width", "0.8pt"})
 			}
 
 			pv := sgmmath.NewVector(star, hyperlane.To).ToPolar()
-			r.createPath(r.canvas, style,
+			pathEl := r.layer.CreatePath(style,
 				NewPath().
 					MoveToPoint(pv.PointAtLength(2*starHalfSize)).
 					LineToPoint(pv.PointAtLength(pv.Length-2*starHalfSize)))
+			if r.opts.Interactive {
+				fromId, toId := starId, hyperlane.ToId
+				if fromId > toId {
+					fromId, toId = toId, fromId
+				}
+				pathEl.SetId(fmt.Sprintf("hyperlane-%d-%d", fromId, toId))
+			}
 		}
 
 		renderedStars[starId] = struct{}{}
@@ -0,0 +1,244 @@
+package sgmrender
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	geoJSONTolerance = 0.05
+
+	GeoJSONKindCountry   = "country"
+	GeoJSONKindSector    = "sector"
+	GeoJSONKindStar      = "star"
+	GeoJSONKindHyperlane = "hyperlane"
+)
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONPosition [2]float64
+
+// BuildGeoJSON exports territories of countries and sectors as polygons,
+// stars as points and hyperlanes as lines. Coordinates are galaxy
+// coordinates from the save game, so the map is mirrored horizontally.
+// Features are filtered with fog of war like in the rendered map.
+func (r *Renderer) BuildGeoJSON() *GeoJSONFeatureCollection {
+	fc := &GeoJSONFeatureCollection{Type: "FeatureCollection"}
+
+	cr := r.getCountryRenderer()
+	countrySegs := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		if !r.isExplored(s) {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+		ownerId := s.Owner()
+		return ownerId, uint64(ownerId)
+	})
+	for _, seg := range countrySegs {
+		fc.Features = append(fc.Features, r.buildSegmentFeature(cr, seg, GeoJSONKindCountry))
+	}
+
+	sectorSegs := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		if !r.isExplored(s) || s.Sector == nil {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+		ownerId := s.Owner()
+		return ownerId, uint64(s.SectorId) | (uint64(ownerId) << 32)
+	})
+	for _, seg := range sectorSegs {
+		feature := r.buildSegmentFeature(cr, seg, GeoJSONKindSector)
+		sectorId := sgm.SectorId(seg.id & 0xffffffff)
+		feature.Properties["sector_id"] = sectorId
+		if sector := r.state.Sectors[sectorId]; sector != nil {
+			feature.Properties["sector_name"] = sector.Name()
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+
+	starIds := make([]sgm.StarId, 0, len(r.state.Stars))
+	for starId := range r.state.Stars {
+		starIds = append(starIds, starId)
+	}
+	sort.Slice(starIds, func(i, j int) bool { return starIds[i] < starIds[j] })
+
+	for _, starId := range starIds {
+		fc.Features = append(fc.Features, r.buildStarFeature(starId, r.state.Stars[starId]))
+	}
+	for _, starId := range starIds {
+		star := r.state.Stars[starId]
+		for _, hyperlane := range star.Hyperlanes {
+			if hyperlane.ToId < starId || hyperlane.To == nil {
+				// Each hyperlane is listed by both stars
+				continue
+			}
+			if !r.isExplored(star) && !r.isExplored(hyperlane.To) {
+				continue
+			}
+
+			fc.Features = append(fc.Features, GeoJSONFeature{
+				Type: "Feature",
+				Geometry: GeoJSONGeometry{
+					Type: "LineString",
+					Coordinates: []geoJSONPosition{
+						newGeoJSONPosition(star.Point()),
+						newGeoJSONPosition(hyperlane.To.Point()),
+					},
+				},
+				Properties: map[string]interface{}{
+					"kind":      GeoJSONKindHyperlane,
+					"from_id":   starId,
+					"from_name": star.Name(),
+					"to_id":     hyperlane.ToId,
+					"to_name":   hyperlane.To.Name(),
+				},
+			})
+		}
+	}
+
+	return fc
+}
+
+func (r *Renderer) WriteGeoJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(r.BuildGeoJSON())
+}
+
+func newGeoJSONPosition(p sgmmath.Point) geoJSONPosition {
+	return geoJSONPosition{-p.X, p.Y}
+}
+
+func (r *Renderer) buildSegmentFeature(cr *countryRenderer, seg *countrySegment, kind string) GeoJSONFeature {
+	country := r.state.Countries[seg.countryId]
+	strokeColor, fillColor := r.countryMapColors(seg.countryId, country)
+
+	return GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONGeometry{
+			Type:        "Polygon",
+			Coordinates: buildGeoJSONPolygon(cr.buildPath(seg)),
+		},
+		Properties: map[string]interface{}{
+			"kind":         kind,
+			"country_id":   seg.countryId,
+			"country_name": r.countryName(seg.countryId, country),
+			"stars":        len(seg.stars),
+			"has_capital":  seg.flags&countrySegHasCapital != 0,
+			"stroke":       strokeColor,
+			"fill":         fillColor,
+		},
+	}
+}
+
+// buildGeoJSONPolygon converts borders of the segment into polygon rings.
+// The largest ring is the exterior one oriented counterclockwise, others
+// are holes left by enclaves.
+func buildGeoJSONPolygon(path Path) [][]geoJSONPosition {
+	var rings [][]geoJSONPosition
+	var areas []float64
+	exterior := 0
+	for _, line := range outlineFromPath(path).flatten(geoJSONTolerance) {
+		if len(line.points) < 3 {
+			continue
+		}
+
+		ring := make([]geoJSONPosition, 0, len(line.points)+1)
+		for _, p := range line.points {
+			ring = append(ring, newGeoJSONPosition(p))
+		}
+		if ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+
+		area := 0.0
+		for i := 1; i < len(ring); i++ {
+			area += ring[i-1][0]*ring[i][1] - ring[i][0]*ring[i-1][1]
+		}
+		if len(areas) > 0 && math.Abs(area) > math.Abs(areas[exterior]) {
+			exterior = len(areas)
+		}
+		rings = append(rings, ring)
+		areas = append(areas, area)
+	}
+	if len(rings) == 0 {
+		return rings
+	}
+
+	rings[0], rings[exterior] = rings[exterior], rings[0]
+	areas[0], areas[exterior] = areas[exterior], areas[0]
+	for i, ring := range rings {
+		if (i == 0) != (areas[i] > 0) {
+			for j, k := 0, len(ring)-1; j < k; j, k = j+1, k-1 {
+				ring[j], ring[k] = ring[k], ring[j]
+			}
+		}
+	}
+	return rings
+}
+
+func (r *Renderer) buildStarFeature(starId sgm.StarId, star *sgm.Star) GeoJSONFeature {
+	props := map[string]interface{}{
+		"kind":     GeoJSONKindStar,
+		"id":       starId,
+		"name":     star.Name(),
+		"explored": r.isExplored(star),
+	}
+	if r.isExplored(star) {
+		if ownerId := star.Owner(); ownerId != sgm.DefaultCountryId {
+			props["owner_id"] = ownerId
+			props["owner_name"] = r.countryName(ownerId, r.state.Countries[ownerId])
+		}
+		if occupierId := star.Occupier(); occupierId != sgm.DefaultCountryId {
+			props["occupier_id"] = occupierId
+			props["occupier_name"] = r.countryName(occupierId, r.state.Countries[occupierId])
+		}
+		if star.Sector != nil {
+			props["sector_id"] = star.SectorId
+			props["sector_name"] = star.Sector.Name()
+		}
+		if starbase := star.PrimaryStarbase(); starbase != nil {
+			props["starbase_level"] = strings.TrimPrefix(starbase.Level, "starbase_level_")
+		}
+
+		pops := 0
+		for _, planet := range star.Planets {
+			pops += planet.EmployablePops
+		}
+		props["pops"] = pops
+		props["capital"] = star.HasCapital()
+
+		bypasses := star.Bypasses()
+		if bypasses == nil {
+			bypasses = []string{}
+		}
+		props["bypasses"] = bypasses
+	}
+
+	return GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: newGeoJSONPosition(star.Point()),
+		},
+		Properties: props,
+	}
+}
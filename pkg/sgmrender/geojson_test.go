@@ -0,0 +1,78 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func geoJSONRingArea(ring []geoJSONPosition) float64 {
+	area := 0.0
+	for i := 1; i < len(ring); i++ {
+		area += ring[i-1][0]*ring[i][1] - ring[i][0]*ring[i-1][1]
+	}
+	return area / 2
+}
+
+func TestBuildGeoJSONPolygon(t *testing.T) {
+	square := func(path Path, x0, y0, x1, y1 float64) Path {
+		return path.MoveTo(x0, y0).LineTo(x1, y0).LineTo(x1, y1).LineTo(x0, y1).Complete()
+	}
+
+	tcs := []struct {
+		name  string
+		path  Path
+		areas []float64
+	}{
+		{
+			name:  "Exterior",
+			path:  square(NewPath(), 0, 0, 10, 10),
+			areas: []float64{100},
+		},
+		{
+			name:  "ExteriorReversed",
+			path:  NewPath().MoveTo(0, 0).LineTo(0, 10).LineTo(10, 10).LineTo(10, 0).Complete(),
+			areas: []float64{100},
+		},
+		{
+			// Enclave comes first, but the largest ring becomes exterior
+			name:  "Hole",
+			path:  square(square(NewPath(), 2, 2, 4, 4), 0, 0, 10, 10),
+			areas: []float64{100, -4},
+		},
+		{
+			name: "HolesReversed",
+			path: square(square(
+				NewPath().MoveTo(6, 6).LineTo(6, 9).LineTo(9, 9).LineTo(9, 6).Complete(),
+				0, 0, 10, 10), 2, 2, 4, 4),
+			areas: []float64{100, -9, -4},
+		},
+		{
+			name:  "Degenerate",
+			path:  NewPath().MoveTo(0, 0).LineTo(10, 0),
+			areas: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rings := buildGeoJSONPolygon(tc.path)
+			if !assert.Len(t, rings, len(tc.areas)) {
+				return
+			}
+
+			for i, ring := range rings {
+				assert.Equal(t, ring[0], ring[len(ring)-1], "ring %d is not closed", i)
+				assert.InDelta(t, tc.areas[i], geoJSONRingArea(ring), 1e-9, "ring %d", i)
+			}
+		})
+	}
+}
+
+func TestBuildGeoJSONPolygonMirrored(t *testing.T) {
+	rings := buildGeoJSONPolygon(NewPath().MoveTo(1, 2).LineTo(5, 2).LineTo(5, 7).Complete())
+	if assert.Len(t, rings, 1) {
+		assert.ElementsMatch(t, []geoJSONPosition{{-1, 2}, {-5, 2}, {-5, 7}},
+			rings[0][:len(rings[0])-1])
+	}
+}
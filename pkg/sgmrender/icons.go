@@ -0,0 +1,151 @@
+package sgmrender
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// Icons are SVG files named by the manifest below. Users may supply a
+// directory of icons which replace embedded ones with the same name or add
+// icons missing from the embedded set. Icons are drawn in the box of
+// svgIconSize units, so width and height of files should be 16 with
+// arbitrary viewBox.
+//
+// Icon manifest:
+//
+//	starbase-<role>          starbase modules and buildings: shipyard,
+//	                         bastion, anchorage, trading-hub
+//	bypass-<type>            wormhole, gateway, gateway-ruined, lgate,
+//	                         relay, catapult
+//	megastructure-<type>     megastructure type with underscores replaced
+//	                         by dashes, i.e. megastructure-dyson-sphere
+//	megastructure-planet     background of megastructures built near planets
+//	megastructure-ruined     ruined megastructures of any type
+//	habitat                  habitat colonies
+//	colony-<kind>            capital, ecumenopolis
+//	battle-<side>-won        attacker, defender
+//	monster-<class>          leviathan, guardian, amoeba, crystal, drone,
+//	                         cloud, whale, marauder
+//	crisis-<structure>       portal, hub
+//
+// Icons which are missing in both sets are drawn as placeholder glyphs.
+
+// IconCanvas is implemented by canvases which draw icons, so they can load
+// them from the user directory
+type IconCanvas interface {
+	Canvas
+	SetIconDir(dir string)
+}
+
+// IconNames returns names of all icons which may be requested by renderer
+func IconNames() []string {
+	names := []string{
+		"megastructure-planet",
+		"megastructure-ruined",
+		"habitat",
+		"colony-capital",
+		"colony-ecumenopolis",
+		"battle-attacker-won",
+		"battle-defender-won",
+	}
+	for role := sgm.StarbaseRole(0); role < sgm.StarbaseRoleMax; role++ {
+		names = append(names, "starbase-"+role.String())
+	}
+	for _, bypass := range []string{
+		sgm.BypassWormhole, sgm.BypassLGate, sgm.BypassGateway,
+		sgm.BypassGatewayRuined, sgm.BypassHyperRelay, sgm.BypassQuantumCatapult,
+	} {
+		names = append(names, "bypass-"+bypass)
+	}
+	for msType := range sgm.MegastructureSize {
+		names = append(names, "megastructure-"+strings.ReplaceAll(msType, "_", "-"))
+	}
+	for class := sgm.CountryClassEmpire + 1; class < sgm.CountryClassMax; class++ {
+		names = append(names, "monster-"+class.String())
+	}
+	for cst := sgm.CrisisStructureType(0); cst < sgm.CrisisStructureMax; cst++ {
+		names = append(names, "crisis-"+cst.String())
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// HasEmbeddedIcon returns true if icon is a part of the embedded set
+func HasEmbeddedIcon(name string) bool {
+	_, err := iconsFS.ReadFile("icons/" + name + ".svg")
+	return err == nil
+}
+
+// loadIcon reads icon from the user directory if it is set and has it, or
+// the embedded icon. Returns placeholder if icon is missing or broken.
+func loadIcon(iconDir, iconFile string) *etree.Document {
+	if iconDir != "" {
+		buf, err := os.ReadFile(filepath.Join(iconDir, iconFile+".svg"))
+		if err == nil {
+			if icon := parseIcon(iconFile, buf); icon != nil {
+				return icon
+			}
+		} else if !os.IsNotExist(err) {
+			log.Printf("error reading icon %s: %s", iconFile, err.Error())
+		}
+	}
+
+	buf, err := iconsFS.ReadFile("icons/" + iconFile + ".svg")
+	if err != nil {
+		log.Printf("warn: missing icon %s, drawing placeholder", iconFile)
+		return placeholderIcon()
+	}
+	if icon := parseIcon(iconFile, buf); icon != nil {
+		return icon
+	}
+	return placeholderIcon()
+}
+
+func parseIcon(iconFile string, buf []byte) *etree.Document {
+	icon := etree.NewDocument()
+	err := icon.ReadFromBytes(buf)
+	if err != nil || icon.Root() == nil {
+		log.Printf("error parsing icon %s: %v", iconFile, err)
+		return nil
+	}
+
+	// Remove some inkscape junk
+	svg := icon.Root()
+	for _, tag := range []string{"defs", "metadata"} {
+		if el := svg.FindElement(tag); el != nil {
+			svg.RemoveChild(el)
+		}
+	}
+	return icon
+}
+
+// placeholderIcon generates crossed box in colors of embedded icons
+func placeholderIcon() *etree.Document {
+	icon := etree.NewDocument()
+	svg := icon.CreateElement("svg")
+	svg.CreateAttr("xmlns", "http://www.w3.org/2000/svg")
+	svg.CreateAttr("width", fmt.Sprint(svgIconSize))
+	svg.CreateAttr("height", fmt.Sprint(svgIconSize))
+	svg.CreateAttr("viewBox", fmt.Sprintf("0 0 %d %d", svgIconSize, svgIconSize))
+
+	box := svg.CreateElement("rect")
+	box.CreateAttr("x", "2")
+	box.CreateAttr("y", "2")
+	box.CreateAttr("width", "12")
+	box.CreateAttr("height", "12")
+	box.CreateAttr("style", "fill:#535d6c;stroke:#b3b3b3;stroke-width:1.2")
+
+	cross := svg.CreateElement("path")
+	cross.CreateAttr("d", "M 5,5 11,11 M 11,5 5,11")
+	cross.CreateAttr("style", "fill:none;stroke:#b3b3b3;stroke-width:1.2;stroke-linecap:round")
+	return icon
+}
@@ -0,0 +1,113 @@
+package sgmrender
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIconName = "habitat"
+
+func iconString(t *testing.T, icon *etree.Document) string {
+	s, err := icon.WriteToString()
+	assert.NoError(t, err)
+	return s
+}
+
+func newTestIconDir(t *testing.T, icons map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range icons {
+		err := ioutil.WriteFile(filepath.Join(dir, name+".svg"), []byte(data), 0644)
+		assert.NoError(t, err)
+	}
+	return dir
+}
+
+func TestLoadIcon(t *testing.T) {
+	assert.True(t, HasEmbeddedIcon(testIconName))
+	embedded := iconString(t, loadIcon("", testIconName))
+	placeholder := iconString(t, placeholderIcon())
+	assert.NotEqual(t, embedded, placeholder)
+
+	iconDir := newTestIconDir(t, map[string]string{
+		testIconName:   `<svg id="user"><defs/><metadata/><rect width="16" height="16"/></svg>`,
+		"broken":       `not an icon`,
+		"colony-extra": `<svg id="extra"/>`,
+		// Broken override of embedded icon
+		"colony-ecumenopolis": ``,
+	})
+
+	tcs := []struct {
+		name     string
+		iconDir  string
+		iconFile string
+		expected string
+	}{
+		{
+			name:     "Embedded",
+			iconFile: testIconName,
+			expected: embedded,
+		},
+		{
+			name:     "Override",
+			iconDir:  iconDir,
+			iconFile: testIconName,
+			expected: `<svg id="user"><rect width="16" height="16"/></svg>`,
+		},
+		{
+			name:     "UserOnly",
+			iconDir:  iconDir,
+			iconFile: "colony-extra",
+			expected: `<svg id="extra"/>`,
+		},
+		{
+			name:     "NotInUserDir",
+			iconDir:  iconDir,
+			iconFile: "colony-capital",
+			expected: iconString(t, loadIcon("", "colony-capital")),
+		},
+		{
+			name:     "Missing",
+			iconDir:  iconDir,
+			iconFile: "colony-missing",
+			expected: placeholder,
+		},
+		{
+			name:     "Broken",
+			iconDir:  iconDir,
+			iconFile: "broken",
+			expected: placeholder,
+		},
+		{
+			name:     "BrokenOverride",
+			iconDir:  iconDir,
+			iconFile: "colony-ecumenopolis",
+			expected: iconString(t, loadIcon("", "colony-ecumenopolis")),
+		},
+		{
+			name:     "MissingDir",
+			iconDir:  filepath.Join(iconDir, "missing"),
+			iconFile: testIconName,
+			expected: embedded,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, iconString(t, loadIcon(tc.iconDir, tc.iconFile)))
+		})
+	}
+}
+
+func TestPlaceholderIcon(t *testing.T) {
+	svg := placeholderIcon().Root()
+	if assert.NotNil(t, svg) {
+		assert.Equal(t, "16", svg.SelectAttrValue("width", ""))
+		assert.Equal(t, "0 0 16 16", svg.SelectAttrValue("viewBox", ""))
+		assert.NotNil(t, svg.SelectElement("rect"))
+		assert.NotNil(t, svg.SelectElement("path"))
+	}
+}
@@ -0,0 +1,139 @@
+package sgmrender
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// Interactive maps carry ids and data-* attributes of stars and countries,
+// so scripts can show inspection panels and search systems. Ids are built
+// from ids of objects in save game and don't change between renders.
+
+const interactiveListSeparator = "; "
+
+func starElementId(starId sgm.StarId) string {
+	return fmt.Sprintf("star-%d", starId)
+}
+
+// elementId identifies segment by its country and the star with the
+// smallest id as a country may have several disconnected segments
+func (seg *countrySegment) elementId(prefix string) string {
+	minStarId := sgm.DefaultStarId
+	for starId := range seg.stars {
+		if starId < minStarId {
+			minStarId = starId
+		}
+	}
+	return fmt.Sprintf("%s-%d-%d", prefix, seg.countryId, minStarId)
+}
+
+func (r *Renderer) countrySystemCount(countryId sgm.CountryId) int {
+	if r.countrySystems == nil {
+		r.countrySystems = make(map[sgm.CountryId]int)
+		for _, star := range r.state.Stars {
+			if r.isExplored(star) {
+				r.countrySystems[star.Owner()]++
+			}
+		}
+	}
+	return r.countrySystems[countryId]
+}
+
+func (r *Renderer) annotateCountry(el Element, seg *countrySegment, name string) {
+	if !r.opts.Interactive {
+		return
+	}
+
+	systems := r.countrySystemCount(seg.countryId)
+	el.SetId(seg.elementId("country"))
+	el.SetData("country-id", fmt.Sprint(seg.countryId))
+	el.SetData("name", name)
+	el.SetData("systems", fmt.Sprint(systems))
+	el.SetData("segment-systems", fmt.Sprint(len(seg.stars)))
+
+	title := fmt.Sprintf("%s: %d systems", name, systems)
+	if len(seg.stars) != systems {
+		title += fmt.Sprintf(", %d in this area", len(seg.stars))
+	}
+	el.SetTitle(title)
+}
+
+// annotateStar attaches data to the group of the star in stars layer, groups
+// in other layers only refer to it with data-star-id and share the title
+func (r *Renderer) annotateStar(ctx *starRenderContext) {
+	if !r.opts.Interactive {
+		return
+	}
+
+	g, starId, star := ctx.g, ctx.starId, ctx.star
+	g.SetId(starElementId(starId))
+	g.SetData("star-id", fmt.Sprint(starId))
+	g.SetData("name", star.Name())
+	if !r.isExplored(star) {
+		ctx.title = star.Name()
+		g.SetTitle(ctx.title)
+		return
+	}
+
+	title := []string{star.Name()}
+	if ownerId := star.Owner(); ownerId != sgm.DefaultCountryId {
+		owner := r.countryName(ownerId, r.state.Countries[ownerId])
+		g.SetData("owner-id", fmt.Sprint(ownerId))
+		g.SetData("owner", owner)
+		title = append(title, "Owner: "+owner)
+	}
+	if occupierId := star.Occupier(); occupierId != sgm.DefaultCountryId {
+		occupier := r.countryName(occupierId, r.state.Countries[occupierId])
+		g.SetData("occupier", occupier)
+		title = append(title, "Occupied by: "+occupier)
+	}
+
+	if starbase := star.PrimaryStarbase(); starbase != nil {
+		level := strings.TrimPrefix(starbase.Level, "starbase_level_")
+		g.SetData("starbase", level)
+
+		slots := make([]int, 0, len(starbase.Modules))
+		for slot := range starbase.Modules {
+			slots = append(slots, slot)
+		}
+		sort.Ints(slots)
+		modules := make([]string, 0, len(slots))
+		for _, slot := range slots {
+			modules = append(modules, starbase.Modules[slot])
+		}
+		g.SetData("modules", strings.Join(modules, interactiveListSeparator))
+
+		if len(modules) > 0 {
+			level += " (" + strings.Join(modules, ", ") + ")"
+		}
+		title = append(title, "Starbase: "+level)
+	}
+
+	var planets []string
+	for _, planet := range star.Planets {
+		if planet.EmployablePops > 0 {
+			planets = append(planets, fmt.Sprintf("%s (%d pops)", planet.Name(), planet.EmployablePops))
+		}
+	}
+	if len(planets) > 0 {
+		g.SetData("planets", strings.Join(planets, interactiveListSeparator))
+		title = append(title, "Planets: "+strings.Join(planets, ", "))
+	}
+
+	if r.isObserved(star) && !r.opts.NoFleets {
+		var fleets []string
+		for _, fleet := range star.MobileMilitaryFleets() {
+			fleets = append(fleets, fmt.Sprintf("%s (%s)", fleet.Name(), fleet.MilitaryPowerString()))
+		}
+		if len(fleets) > 0 {
+			g.SetData("fleets", strings.Join(fleets, interactiveListSeparator))
+			title = append(title, "Fleets: "+strings.Join(fleets, ", "))
+		}
+	}
+
+	ctx.title = strings.Join(title, "\n")
+	g.SetTitle(ctx.title)
+}
@@ -0,0 +1,79 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+// newInteractiveTestState adds owner, occupier, starbase, planets and fleet
+// to the second star of the test state
+func newInteractiveTestState() *sgm.GameState {
+	state := newTestState()
+	state.Countries[1] = &sgm.Country{NameString: "United Nations"}
+	state.Countries[2] = &sgm.Country{NameString: "Khan Horde"}
+
+	star := state.Stars[1]
+	star.NameString = "Sol"
+	star.Sector = &sgm.Sector{Owner: 1}
+	star.Starbases = []*sgm.Starbase{{
+		Level:   sgm.StarbaseStarhold,
+		Modules: map[int]string{2: "trading_hub", 0: "shipyard", 1: "anchorage"},
+		Station: &sgm.Ship{Fleet: &sgm.Fleet{
+			OwnershipStatus: sgm.FleetOwnershipLostControl,
+			DebtorId:        2,
+		}},
+	}}
+	star.Planets = []*sgm.Planet{
+		{NameString: "Earth", EmployablePops: 42},
+		{NameString: "Mars"},
+	}
+	star.Fleets = []*sgm.Fleet{{
+		NameString:    "Home Guard",
+		Mobile:        true,
+		MilitaryPower: 1234,
+		Ships:         []*sgm.Ship{{ArmyId: sgm.DefaultArmyId}},
+	}}
+	return state
+}
+
+func annotateTestStar(opts RenderOptions) map[string]string {
+	state := newInteractiveTestState()
+	r := NewRenderer(state, opts)
+	g := r.canvas.CreateGroup(Style{})
+	r.annotateStar(&starRenderContext{starId: 1, star: state.Stars[1], g: g})
+
+	attrs := make(map[string]string)
+	for _, attr := range g.(svgGroup).el.Attr {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestAnnotateStar(t *testing.T) {
+	attrs := annotateTestStar(RenderOptions{Interactive: true})
+	assert.Equal(t, map[string]string{
+		"id":            "star-1",
+		"data-star-id":  "1",
+		"data-name":     "Sol",
+		"data-owner-id": "1",
+		"data-owner":    "United Nations",
+		"data-occupier": "Khan Horde",
+		"data-starbase": "starhold",
+		"data-modules":  "shipyard; anchorage; trading_hub",
+		"data-planets":  "Earth (42 pops)",
+		"data-fleets":   "Home Guard (1234)",
+	}, attrs)
+}
+
+func TestAnnotateStarNoFleets(t *testing.T) {
+	attrs := annotateTestStar(RenderOptions{Interactive: true, NoFleets: true})
+	assert.NotContains(t, attrs, "data-fleets")
+	assert.Equal(t, "Sol", attrs["data-name"])
+}
+
+func TestAnnotateStarNotInteractive(t *testing.T) {
+	assert.Empty(t, annotateTestStar(RenderOptions{}))
+}
@@ -0,0 +1,90 @@
+package sgmrender
+
+import (
+	"fmt"
+)
+
+// Each pass of renderer draws into its own layer, so users may hide fleets
+// or names in the rendered map. Layers are created in advance in the
+// order of drawing, so passes may run in any order.
+
+const (
+	layerBackground   = "background"
+	layerCountries    = "countries"
+	layerOccupation   = "occupation"
+	layerOverlays     = "overlays"
+	layerWarFronts    = "war-fronts"
+	layerGrid         = "grid"
+	layerHyperlanes   = "hyperlanes"
+	layerStars        = "stars"
+	layerStarbases    = "starbases"
+	layerFleets       = "fleets"
+	layerBattles      = "battles"
+	layerChokepoints  = "chokepoints"
+	layerCountryNames = "country-names"
+	layerStarNames    = "star-names"
+	layerLegend       = "legend"
+)
+
+type renderLayer struct {
+	id     string
+	label  string
+	detail DetailLevel
+}
+
+var renderLayers = []renderLayer{
+	{layerBackground, "Background", DetailCountries},
+	{layerCountries, "Countries", DetailCountries},
+	{layerOccupation, "Occupation", DetailCountries},
+	{layerOverlays, "Overlays", DetailCountries},
+	{layerWarFronts, "War Fronts", DetailCountries},
+	{layerGrid, "Grid", DetailCountries},
+	{layerHyperlanes, "Hyperlanes", DetailStars},
+	{layerStars, "Stars", DetailStars},
+	{layerStarbases, "Starbases", DetailStars},
+	{layerFleets, "Fleets", DetailStars},
+	{layerBattles, "Battles", DetailStars},
+	{layerChokepoints, "Chokepoints", DetailStars},
+	{layerCountryNames, "Country Names", DetailCountries},
+	{layerStarNames, "Star Names", DetailStars},
+	{layerLegend, "Legend", DetailCountries},
+}
+
+func (r *Renderer) createLayers() {
+	r.setDetailLevel(DetailCountries)
+	r.layers = make(map[string]Group, len(renderLayers))
+	for _, layer := range renderLayers {
+		r.layers[layer.id] = r.canvas.CreateLayer(layer.id, layer.label)
+	}
+}
+
+// useLayer makes layer current, so elements are drawn into it
+func (r *Renderer) useLayer(id string) {
+	for _, layer := range renderLayers {
+		if layer.id == id {
+			r.layer = r.layers[id]
+			r.setDetailLevel(layer.detail)
+			return
+		}
+	}
+	panic(fmt.Sprintf("unknown layer %s", id))
+}
+
+// starLayerGroup returns group with origin in the star in the layer. Stars
+// have separate groups in each layer they are drawn into.
+func (r *Renderer) starLayerGroup(ctx *starRenderContext, id string) Group {
+	if g, ok := ctx.layerGroups[id]; ok {
+		return g
+	}
+
+	g := r.layers[id].CreateTranslatedGroup(ctx.star.Point())
+	if r.opts.Interactive {
+		g.SetData("star-id", fmt.Sprint(ctx.starId))
+		g.SetTitle(ctx.title)
+	}
+	if ctx.layerGroups == nil {
+		ctx.layerGroups = make(map[string]Group)
+	}
+	ctx.layerGroups[id] = g
+	return g
+}
@@ -0,0 +1,64 @@
+// Shows list of layers in the top right corner of the map below the war
+// panel, clicking the layer name hides or shows it. Runs only if SVG is
+// opened as a document, web/map.html draws its own controls.
+(function () {
+  var svgNS = 'http://www.w3.org/2000/svg';
+  var inkscapeNS = 'http://www.inkscape.org/namespaces/inkscape';
+
+  var root = document.documentElement;
+  if (!root || root.namespaceURI != svgNS) {
+    return;
+  }
+
+  function createLayerControl(controls, layer, x, y) {
+    var label = layer.getAttributeNS(inkscapeNS, 'label') || layer.id;
+    var text = document.createElementNS(svgNS, 'text');
+    text.setAttribute('x', x);
+    text.setAttribute('y', y);
+
+    function update() {
+      var hidden = layer.style.display == 'none';
+      text.textContent = (hidden ? '☐ ' : '☑ ') + label;
+    }
+    text.addEventListener('click', function () {
+      layer.style.display = layer.style.display == 'none' ? '' : 'none';
+      update();
+    });
+
+    update();
+    controls.appendChild(text);
+  }
+
+  window.addEventListener('load', function () {
+    var viewBox = root.viewBox.baseVal;
+    var fontSize = viewBox.height / 80;
+
+    var style = document.createElementNS(svgNS, 'style');
+    style.textContent =
+      '.layer-controls text { font-family: sans-serif; font-size: ' + fontSize + 'px; ' +
+      'text-anchor: end; fill: #f2f2f2; stroke: #212f3c; stroke-width: ' + fontSize / 10 + 'px; ' +
+      'paint-order: stroke; cursor: pointer; user-select: none; }\n' +
+      '@media print { .layer-controls { display: none; } }';
+    root.appendChild(style);
+
+    var controls = document.createElementNS(svgNS, 'g');
+    controls.setAttribute('class', 'layer-controls');
+
+    var x = viewBox.x + viewBox.width - fontSize;
+    var y = viewBox.y + 2 * fontSize;
+    var warSummary = document.getElementById('war-summary');
+    if (warSummary) {
+      var bbox = warSummary.getBBox();
+      y = Math.max(y, bbox.y + bbox.height + 2 * fontSize);
+    }
+    var groups = root.getElementsByTagNameNS(svgNS, 'g');
+    for (var i = 0; i < groups.length; i++) {
+      var layer = groups[i];
+      if (layer.getAttributeNS(inkscapeNS, 'groupmode') == 'layer' && layer.childElementCount > 0) {
+        createLayerControl(controls, layer, x, y);
+        y += 1.4 * fontSize;
+      }
+    }
+    root.appendChild(controls);
+  });
+})();
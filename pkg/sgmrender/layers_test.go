@@ -0,0 +1,82 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+)
+
+func renderTestSVG(opts RenderOptions) *SVGCanvas {
+	r := NewRenderer(newTestState(), opts)
+	r.Render()
+	return r.canvas.(*SVGCanvas)
+}
+
+func svgLayers(c *SVGCanvas) []*etree.Element {
+	var layers []*etree.Element
+	for _, el := range c.el.ChildElements() {
+		if el.SelectAttrValue("inkscape:groupmode", "") == "layer" {
+			layers = append(layers, el)
+		}
+	}
+	return layers
+}
+
+func TestCreateLayers(t *testing.T) {
+	c := renderTestSVG(RenderOptions{})
+
+	layers := svgLayers(c)
+	if assert.Len(t, layers, len(renderLayers)) {
+		for i, layer := range renderLayers {
+			assert.Equal(t, "layer-"+layer.id, layers[i].SelectAttrValue("id", ""))
+			assert.Equal(t, layer.label, layers[i].SelectAttrValue("inkscape:label", ""))
+		}
+	}
+	assert.Nil(t, c.el.SelectElement("script"))
+
+	// Elements are drawn into their layers regardless of order of passes
+	stars := c.el.FindElement("g[@id='layer-stars']")
+	if assert.NotNil(t, stars) {
+		assert.Len(t, stars.ChildElements(), len(newTestState().Stars))
+	}
+	legend := c.el.FindElement("g[@id='layer-legend']")
+	if assert.NotNil(t, legend) {
+		assert.NotEmpty(t, legend.FindElements(".//text"))
+	}
+	background := c.el.FindElement("g[@id='layer-background']")
+	if assert.NotNil(t, background) {
+		assert.NotNil(t, background.SelectElement("rect"))
+	}
+}
+
+func TestCreateLayersInteractive(t *testing.T) {
+	c := renderTestSVG(RenderOptions{Interactive: true})
+
+	children := c.el.ChildElements()
+	scriptIndex, layerIndex := -1, -1
+	for i, el := range children {
+		if el.Tag == "script" && scriptIndex < 0 {
+			scriptIndex = i
+		}
+		if el.SelectAttrValue("inkscape:groupmode", "") == "layer" && layerIndex < 0 {
+			layerIndex = i
+		}
+	}
+	assert.NotEqual(t, -1, scriptIndex)
+	assert.Less(t, scriptIndex, layerIndex)
+	assert.Len(t, svgLayers(c), len(renderLayers))
+}
+
+func TestUseLayer(t *testing.T) {
+	r := NewCanvasRenderer(newTestState(), RenderOptions{}, NewTileCanvasFactory(TileOptions{}))
+	scene := r.canvas.(*TileCanvas).Scene
+	r.createLayers()
+
+	for _, layer := range renderLayers {
+		r.useLayer(layer.id)
+		assert.Equal(t, r.layers[layer.id], r.layer)
+		assert.Equal(t, layer.detail, scene.detail, layer.id)
+	}
+	assert.Panics(t, func() { r.useLayer("unknown") })
+}
@@ -0,0 +1,547 @@
+package sgmrender
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	// Magic constant for approximating quarter of a circle with cubic curve
+	circleKappa = 0.5522847498
+
+	maxCurveSegments = 64
+)
+
+// affine is a 2D transformation matrix in the same order as in SVG
+// matrix(a, b, c, d, e, f)
+type affine [6]float64
+
+var identityAffine = affine{1, 0, 0, 1, 0, 0}
+
+func translateAffine(x, y float64) affine {
+	return affine{1, 0, 0, 1, x, y}
+}
+
+func scaleAffine(sx, sy float64) affine {
+	return affine{sx, 0, 0, sy, 0, 0}
+}
+
+func rotateAffine(deg float64) affine {
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	return affine{cos, sin, -sin, cos, 0, 0}
+}
+
+func (m affine) apply(p sgmmath.Point) sgmmath.Point {
+	return sgmmath.Point{
+		X: m[0]*p.X + m[2]*p.Y + m[4],
+		Y: m[1]*p.X + m[3]*p.Y + m[5],
+	}
+}
+
+// mul returns transformation which applies o and then m
+func (m affine) mul(o affine) affine {
+	return affine{
+		m[0]*o[0] + m[2]*o[1],
+		m[1]*o[0] + m[3]*o[1],
+		m[0]*o[2] + m[2]*o[3],
+		m[1]*o[2] + m[3]*o[3],
+		m[0]*o[4] + m[2]*o[5] + m[4],
+		m[1]*o[4] + m[3]*o[5] + m[5],
+	}
+}
+
+func (m affine) invert() affine {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return identityAffine
+	}
+	return affine{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}
+}
+
+// scale returns average scale factor of the transformation which is used
+// for stroke widths and other lengths
+func (m affine) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// parseTransform parses transform attribute of SVG elements
+func parseTransform(s string) affine {
+	m := identityAffine
+	for {
+		s = strings.TrimLeft(s, " ,\t\n")
+		open := strings.IndexByte(s, '(')
+		end := strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m
+		}
+
+		name := strings.TrimSpace(s[:open])
+		args := parseNumbers(s[open+1 : end])
+		s = s[end+1:]
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		switch name {
+		case "matrix":
+			if len(args) == 6 {
+				m = m.mul(affine{args[0], args[1], args[2], args[3], args[4], args[5]})
+			}
+		case "translate":
+			m = m.mul(translateAffine(arg(0, 0), arg(1, 0)))
+		case "scale":
+			sx := arg(0, 1)
+			m = m.mul(scaleAffine(sx, arg(1, sx)))
+		case "rotate":
+			cx, cy := arg(1, 0), arg(2, 0)
+			m = m.mul(translateAffine(cx, cy)).mul(rotateAffine(arg(0, 0))).
+				mul(translateAffine(-cx, -cy))
+		}
+	}
+}
+
+func parseNumbers(s string) (numbers []float64) {
+	sc := pathScanner{s: s}
+	for {
+		sc.skipSeparators()
+		if sc.eof() {
+			return
+		}
+		n, ok := sc.number()
+		if !ok {
+			return
+		}
+		numbers = append(numbers, n)
+	}
+}
+
+type outlineOp struct {
+	cmd byte
+	pts [3]sgmmath.Point
+}
+
+// outline is a shape made of lines and cubic curves in absolute
+// coordinates. Unlike Path, it can be transformed and flattened into
+// polygons by raster backends.
+type outline []outlineOp
+
+func (o *outline) moveTo(p sgmmath.Point) {
+	*o = append(*o, outlineOp{cmd: 'M', pts: [3]sgmmath.Point{p}})
+}
+
+func (o *outline) lineTo(p sgmmath.Point) {
+	*o = append(*o, outlineOp{cmd: 'L', pts: [3]sgmmath.Point{p}})
+}
+
+func (o *outline) cubeTo(c1, c2, p sgmmath.Point) {
+	*o = append(*o, outlineOp{cmd: 'C', pts: [3]sgmmath.Point{c1, c2, p}})
+}
+
+func (o *outline) quadTo(from, c, p sgmmath.Point) {
+	o.cubeTo(
+		from.Add(c.Sub(from).Mul(2.0/3.0)),
+		p.Add(c.Sub(p).Mul(2.0/3.0)),
+		p)
+}
+
+func (o *outline) close() {
+	*o = append(*o, outlineOp{cmd: 'Z'})
+}
+
+func (o outline) transform(m affine) outline {
+	o2 := make(outline, len(o))
+	for i, op := range o {
+		o2[i].cmd = op.cmd
+		for j := range op.pts {
+			o2[i].pts[j] = m.apply(op.pts[j])
+		}
+	}
+	return o2
+}
+
+func outlineFromPath(path Path) outline {
+	var o outline
+	var cur, start sgmmath.Point
+	for _, el := range path.path {
+		switch el.Command {
+		case 'M':
+			cur, start = el.Point, el.Point
+			o.moveTo(cur)
+		case 'L':
+			cur = el.Point
+			o.lineTo(cur)
+		case 'H':
+			cur.X = el.X
+			o.lineTo(cur)
+		case 'V':
+			cur.Y = el.Y
+			o.lineTo(cur)
+		case 'c':
+			points := append(append([]sgmmath.Point{}, el.Points...), el.Point)
+			for i := 0; i+2 < len(points); i += 3 {
+				end := cur.Add(points[i+2])
+				o.cubeTo(cur.Add(points[i]), cur.Add(points[i+1]), end)
+				cur = end
+			}
+		case 'Q':
+			if len(el.Points) > 0 {
+				o.quadTo(cur, el.Points[0], el.Point)
+			}
+			cur = el.Point
+		case 'Z':
+			o.close()
+			cur = start
+		}
+	}
+	return o
+}
+
+func circleOutline(center sgmmath.Point, radius float64) outline {
+	return ellipseOutline(center, radius, radius)
+}
+
+func ellipseOutline(center sgmmath.Point, rx, ry float64) outline {
+	kx, ky := rx*circleKappa, ry*circleKappa
+	pt := func(x, y float64) sgmmath.Point {
+		return sgmmath.Point{X: center.X + x, Y: center.Y + y}
+	}
+
+	var o outline
+	o.moveTo(pt(rx, 0))
+	o.cubeTo(pt(rx, ky), pt(kx, ry), pt(0, ry))
+	o.cubeTo(pt(-kx, ry), pt(-rx, ky), pt(-rx, 0))
+	o.cubeTo(pt(-rx, -ky), pt(-kx, -ry), pt(0, -ry))
+	o.cubeTo(pt(kx, -ry), pt(rx, -ky), pt(rx, 0))
+	o.close()
+	return o
+}
+
+func rectOutline(rect sgmmath.BoundingRect) outline {
+	var o outline
+	o.moveTo(rect.Min)
+	o.lineTo(sgmmath.Point{X: rect.Max.X, Y: rect.Min.Y})
+	o.lineTo(rect.Max)
+	o.lineTo(sgmmath.Point{X: rect.Min.X, Y: rect.Max.Y})
+	o.close()
+	return o
+}
+
+// polyline is a flattened subpath of outline
+type polyline struct {
+	points []sgmmath.Point
+	closed bool
+}
+
+// flatten converts curves into line segments which deviate from the curve
+// not more than by tolerance
+func (o outline) flatten(tolerance float64) []polyline {
+	var lines []polyline
+	var cur *polyline
+	var last sgmmath.Point
+	ensure := func() {
+		if cur == nil {
+			lines = append(lines, polyline{points: []sgmmath.Point{last}})
+			cur = &lines[len(lines)-1]
+		}
+	}
+
+	for _, op := range o {
+		switch op.cmd {
+		case 'M':
+			lines = append(lines, polyline{points: []sgmmath.Point{op.pts[0]}})
+			cur = &lines[len(lines)-1]
+			last = op.pts[0]
+		case 'L':
+			ensure()
+			cur.points = append(cur.points, op.pts[0])
+			last = op.pts[0]
+		case 'C':
+			ensure()
+			p0, p1, p2, p3 := last, op.pts[0], op.pts[1], op.pts[2]
+			length := p0.Distance(p1) + p1.Distance(p2) + p2.Distance(p3)
+			n := int(math.Ceil(math.Sqrt(length / tolerance)))
+			if n < 1 {
+				n = 1
+			} else if n > maxCurveSegments {
+				n = maxCurveSegments
+			}
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				mt := 1 - t
+				a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+				cur.points = append(cur.points, sgmmath.Point{
+					X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+					Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+				})
+			}
+			last = p3
+		case 'Z':
+			if cur != nil {
+				cur.closed = true
+				last = cur.points[0]
+			}
+			cur = nil
+		}
+	}
+	return lines
+}
+
+// parsePathData parses d attribute of SVG path elements, it is used for
+// icons which are drawn by external editors
+func parsePathData(d string) outline {
+	var o outline
+	var cur, start, lastCtrl sgmmath.Point
+	var prevCmd byte
+	cmd := byte(0)
+
+	sc := pathScanner{s: d}
+	for {
+		sc.skipSeparators()
+		if sc.eof() {
+			return o
+		}
+		if c := sc.s[sc.pos]; (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			sc.pos++
+		} else if cmd == 0 {
+			return o
+		}
+
+		rel := cmd >= 'a'
+		abs := func(p sgmmath.Point) sgmmath.Point {
+			if rel {
+				return cur.Add(p)
+			}
+			return p
+		}
+
+		upper := cmd &^ 0x20
+		var args []float64
+		switch upper {
+		case 'Z':
+			o.close()
+			cur = start
+			prevCmd = 'Z'
+			continue
+		case 'H', 'V':
+			args = sc.numbers(1)
+		case 'M', 'L', 'T':
+			args = sc.numbers(2)
+		case 'S', 'Q':
+			args = sc.numbers(4)
+		case 'C':
+			args = sc.numbers(6)
+		case 'A':
+			args = sc.numbers(7)
+		default:
+			return o
+		}
+		if args == nil {
+			return o
+		}
+
+		switch upper {
+		case 'M':
+			cur = abs(sgmmath.Point{X: args[0], Y: args[1]})
+			start = cur
+			o.moveTo(cur)
+			// Subsequent pairs are implicit lineto commands
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L':
+			cur = abs(sgmmath.Point{X: args[0], Y: args[1]})
+			o.lineTo(cur)
+		case 'H':
+			if rel {
+				cur.X += args[0]
+			} else {
+				cur.X = args[0]
+			}
+			o.lineTo(cur)
+		case 'V':
+			if rel {
+				cur.Y += args[0]
+			} else {
+				cur.Y = args[0]
+			}
+			o.lineTo(cur)
+		case 'C', 'S':
+			var c1 sgmmath.Point
+			if upper == 'C' {
+				c1 = abs(sgmmath.Point{X: args[0], Y: args[1]})
+				args = args[2:]
+			} else if prevCmd == 'C' || prevCmd == 'S' {
+				c1 = cur.Add(cur.Sub(lastCtrl))
+			} else {
+				c1 = cur
+			}
+			c2 := abs(sgmmath.Point{X: args[0], Y: args[1]})
+			end := abs(sgmmath.Point{X: args[2], Y: args[3]})
+			o.cubeTo(c1, c2, end)
+			cur, lastCtrl = end, c2
+		case 'Q', 'T':
+			var c sgmmath.Point
+			if upper == 'Q' {
+				c = abs(sgmmath.Point{X: args[0], Y: args[1]})
+				args = args[2:]
+			} else if prevCmd == 'Q' || prevCmd == 'T' {
+				c = cur.Add(cur.Sub(lastCtrl))
+			} else {
+				c = cur
+			}
+			end := abs(sgmmath.Point{X: args[0], Y: args[1]})
+			o.quadTo(cur, c, end)
+			cur, lastCtrl = end, c
+		case 'A':
+			end := abs(sgmmath.Point{X: args[5], Y: args[6]})
+			o.arcTo(cur, args[0], args[1], args[2], args[3] != 0, args[4] != 0, end)
+			cur = end
+		}
+		prevCmd = upper
+	}
+}
+
+// arcTo approximates elliptical arc with cubic curves, see implementation
+// notes of SVG specification
+func (o *outline) arcTo(from sgmmath.Point, rx, ry, angle float64, large, sweep bool, to sgmmath.Point) {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || from == to {
+		o.lineTo(to)
+		return
+	}
+
+	sinPhi, cosPhi := math.Sincos(angle * math.Pi / 180)
+	dx, dy := (from.X-to.X)/2, (from.Y-to.Y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry)
+	if lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := 0.0
+	if num > 0 && den > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if large == sweep {
+		coef = -coef
+	}
+	cx1, cy1 := coef*rx*y1/ry, -coef*ry*x1/rx
+	cx := cosPhi*cx1 - sinPhi*cy1 + (from.X+to.X)/2
+	cy := sinPhi*cx1 + cosPhi*cy1 + (from.Y+to.Y)/2
+
+	theta1 := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	dTheta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta1
+	if sweep && dTheta < 0 {
+		dTheta += 2 * math.Pi
+	} else if !sweep && dTheta > 0 {
+		dTheta -= 2 * math.Pi
+	}
+
+	point := func(theta float64) sgmmath.Point {
+		sin, cos := math.Sincos(theta)
+		return sgmmath.Point{
+			X: cx + rx*cos*cosPhi - ry*sin*sinPhi,
+			Y: cy + rx*cos*sinPhi + ry*sin*cosPhi,
+		}
+	}
+	derivative := func(theta float64) sgmmath.Point {
+		sin, cos := math.Sincos(theta)
+		return sgmmath.Point{
+			X: -rx*sin*cosPhi - ry*cos*sinPhi,
+			Y: -rx*sin*sinPhi + ry*cos*cosPhi,
+		}
+	}
+
+	n := int(math.Ceil(math.Abs(dTheta) / (math.Pi / 2)))
+	step := dTheta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+	for i := 0; i < n; i++ {
+		t1, t2 := theta1+float64(i)*step, theta1+float64(i+1)*step
+		p1, p2 := point(t1), point(t2)
+		end := p2
+		if i == n-1 {
+			end = to
+		}
+		o.cubeTo(p1.Add(derivative(t1).Mul(k)), p2.Sub(derivative(t2).Mul(k)), end)
+	}
+}
+
+type pathScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *pathScanner) eof() bool {
+	return sc.pos >= len(sc.s)
+}
+
+func (sc *pathScanner) skipSeparators() {
+	for !sc.eof() && strings.IndexByte(" \t\r\n,", sc.s[sc.pos]) >= 0 {
+		sc.pos++
+	}
+}
+
+// number reads floating point number which may be glued to the previous one
+// like in "0.5.5" or "1-2"
+func (sc *pathScanner) number() (float64, bool) {
+	start := sc.pos
+	if !sc.eof() && (sc.s[sc.pos] == '-' || sc.s[sc.pos] == '+') {
+		sc.pos++
+	}
+	seenDot, seenExp := false, false
+	for !sc.eof() {
+		c := sc.s[sc.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !seenDot && !seenExp:
+			seenDot = true
+		case (c == 'e' || c == 'E') && !seenExp:
+			seenExp = true
+			if sc.pos+1 < len(sc.s) && (sc.s[sc.pos+1] == '-' || sc.s[sc.pos+1] == '+') {
+				sc.pos++
+			}
+		default:
+			goto done
+		}
+		sc.pos++
+	}
+done:
+	n, err := strconv.ParseFloat(sc.s[start:sc.pos], 64)
+	if err != nil {
+		sc.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+func (sc *pathScanner) numbers(count int) []float64 {
+	numbers := make([]float64, 0, count)
+	for len(numbers) < count {
+		sc.skipSeparators()
+		n, ok := sc.number()
+		if !ok {
+			return nil
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
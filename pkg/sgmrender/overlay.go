@@ -0,0 +1,330 @@
+package sgmrender
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/YashdalfTheGray/colorcode"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	defaultOverlaySteps   = 16
+	defaultOverlayOpacity = 0.6
+
+	overlayLegendWidth  = 48.0
+	overlayLegendHeight = 3.0
+	overlayLegendLines  = 4
+)
+
+type BlendMode int
+
+const (
+	BlendNormal BlendMode = iota
+	BlendMultiply
+	BlendScreen
+	BlendOverlay
+
+	BlendModeMax
+)
+
+func (mode BlendMode) String() string {
+	return [...]string{
+		"normal",
+		"multiply",
+		"screen",
+		"overlay",
+		"undefined",
+	}[mode]
+}
+
+// ColorRamp is a list of colors evenly spread over range of values
+type ColorRamp []string
+
+var (
+	RampHeat     = ColorRamp{"#fcf3cf", "#f5b041", "#e74c3c", "#78281f"}
+	RampGreen    = ColorRamp{"#e9f7ef", "#58d68d", "#1d8348"}
+	RampBlue     = ColorRamp{"#ebf5fb", "#5dade2", "#1b4f72"}
+	RampDistance = ColorRamp{"#f4d03f", "#1f618d"}
+)
+
+// Color returns color of the ramp at t which is in range [0, 1]
+func (ramp ColorRamp) Color(t float64) string {
+	if len(ramp) == 1 {
+		return ramp[0]
+	}
+
+	t = math.Max(0, math.Min(1, t)) * float64(len(ramp)-1)
+	index := int(math.Floor(t))
+	if index >= len(ramp)-1 {
+		return ramp[len(ramp)-1]
+	}
+	return interpolateColor(ramp[index], ramp[index+1], t-float64(index))
+}
+
+// interpolateColor returns color between from and to, t is in range [0, 1]
+func interpolateColor(from, to string, t float64) string {
+	fromRGB, _ := colorcode.NewHexCode(from).ToRGB()
+	toRGB, _ := colorcode.NewHexCode(to).ToRGB()
+
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	return fmt.Sprintf("#%02x%02x%02x",
+		lerp(fromRGB.R, toRGB.R), lerp(fromRGB.G, toRGB.G), lerp(fromRGB.B, toRGB.B))
+}
+
+// MetricFunc returns value of the metric for the star system or false if
+// system shouldn't be tinted
+type MetricFunc func(star *sgm.Star) (float64, bool)
+
+// MetricOverlay tints Voronoi cells of star systems by value of the metric
+type MetricOverlay struct {
+	Title  string
+	Metric MetricFunc
+	Ramp   ColorRamp
+	Blend  BlendMode
+
+	// Range of values which is mapped onto the ramp. If Max is not greater
+	// than Min, range of metric values on the map is used.
+	Min, Max float64
+	// Number of distinct colors, adjacent cells of the same color are merged
+	Steps   int
+	Opacity float64
+	Format  func(value float64) string
+	// Metric is computed from fleets, so under fog of war it is shown only
+	// for systems in sensor range
+	UsesFleets bool
+
+	min, max float64
+	rendered bool
+}
+
+func (o *MetricOverlay) steps() int {
+	if o.Steps < 2 {
+		return defaultOverlaySteps
+	}
+	return o.Steps
+}
+
+func (o *MetricOverlay) bucket(value float64) int {
+	if o.max <= o.min {
+		return 0
+	}
+	t := math.Max(0, math.Min(1, (value-o.min)/(o.max-o.min)))
+	return int(math.Round(t * float64(o.steps()-1)))
+}
+
+func (o *MetricOverlay) bucketValue(bucket int) float64 {
+	return o.min + (o.max-o.min)*float64(bucket)/float64(o.steps()-1)
+}
+
+func (o *MetricOverlay) format(value float64) string {
+	if o.Format != nil {
+		return o.Format(value)
+	}
+	return fmt.Sprintf("%.f", value)
+}
+
+var builtinOverlays = map[string]func() *MetricOverlay{
+	"pops": func() *MetricOverlay {
+		return &MetricOverlay{
+			Title: "Pops",
+			Ramp:  RampGreen,
+			Metric: func(star *sgm.Star) (float64, bool) {
+				pops := 0
+				for _, planet := range star.Planets {
+					pops += planet.EmployablePops
+				}
+				return float64(pops), pops > 0
+			},
+		}
+	},
+	"military": func() *MetricOverlay {
+		return &MetricOverlay{
+			Title:      "Military presence",
+			Ramp:       RampHeat,
+			UsesFleets: true,
+			Metric: func(star *sgm.Star) (float64, bool) {
+				power := 0.0
+				for _, fleet := range star.MobileMilitaryFleets() {
+					power += fleet.MilitaryPower
+				}
+				return power, power > 0
+			},
+		}
+	},
+	"starbase": func() *MetricOverlay {
+		return &MetricOverlay{
+			Title: "Starbase level",
+			Ramp:  RampBlue,
+			Min:   0,
+			Max:   float64(len(starbaseLevels) - 1),
+			Steps: len(starbaseLevels),
+			Metric: func(star *sgm.Star) (float64, bool) {
+				starbase := star.PrimaryStarbase()
+				if starbase == nil {
+					return 0, false
+				}
+				for level, name := range starbaseLevels {
+					if starbase.Level == name {
+						return float64(level), true
+					}
+				}
+				return 0, false
+			},
+			Format: func(value float64) string {
+				return starbaseLevels[int(value)][len("starbase_level_"):]
+			},
+		}
+	},
+	"planets": func() *MetricOverlay {
+		return &MetricOverlay{
+			Title: "Planets",
+			Ramp:  RampBlue,
+			Metric: func(star *sgm.Star) (float64, bool) {
+				return float64(len(star.Planets)), len(star.Planets) > 0
+			},
+		}
+	},
+}
+
+var starbaseLevels = []string{
+	sgm.StarbaseOutpost,
+	sgm.StarbaseStarport,
+	sgm.StarbaseStarhold,
+	sgm.StarbaseFortress,
+	sgm.StarbaseCitadel,
+}
+
+// BuiltinOverlay creates one of the built-in overlays by its name
+func BuiltinOverlay(name string) (*MetricOverlay, error) {
+	newOverlay, ok := builtinOverlays[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown overlay '%s'", name)
+	}
+	return newOverlay(), nil
+}
+
+func BuiltinOverlayNames() []string {
+	names := make([]string, 0, len(builtinOverlays))
+	for name := range builtinOverlays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddOverlay adds overlay to be drawn over countries on Render()
+func (r *Renderer) AddOverlay(overlay *MetricOverlay) {
+	r.overlays = append(r.overlays, overlay)
+}
+
+func (r *Renderer) renderOverlays() {
+	for _, name := range r.opts.Overlays {
+		overlay, err := BuiltinOverlay(name)
+		if err != nil {
+			log.Printf("error: %s", err.Error())
+			continue
+		}
+		r.AddOverlay(overlay)
+	}
+	if r.opts.ShowThreats {
+		r.AddOverlay(r.newThreatOverlay())
+	}
+	if r.opts.ShowDistances {
+		r.AddOverlay(r.newDistanceOverlay())
+	}
+
+	for _, overlay := range r.overlays {
+		r.renderOverlay(overlay)
+	}
+}
+
+func (r *Renderer) renderOverlay(overlay *MetricOverlay) {
+	values := make(map[*sgm.Star]float64)
+	overlay.min, overlay.max = math.Inf(1), math.Inf(-1)
+	for _, star := range r.state.Stars {
+		if !r.isExplored(star) || (overlay.UsesFleets && !r.isObserved(star)) {
+			continue
+		}
+		if value, ok := overlay.Metric(star); ok {
+			values[star] = value
+			overlay.min = math.Min(overlay.min, value)
+			overlay.max = math.Max(overlay.max, value)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	if overlay.Max > overlay.Min {
+		overlay.min, overlay.max = overlay.Min, overlay.Max
+	}
+	overlay.rendered = true
+
+	opacity := overlay.Opacity
+	if opacity <= 0 {
+		opacity = defaultOverlayOpacity
+	}
+	g := r.layer.CreateGroup(NewStyle(
+		StyleOption{"opacity", fmt.Sprintf("%.2f", opacity)},
+		StyleOption{"mix-blend-mode", overlay.Blend.String()},
+	))
+
+	// Bucket is encoded as a fake country id so segments are merged like
+	// countries are
+	cr := r.getCountryRenderer()
+	segments := cr.buildSegments(func(s *sgm.Star) (sgm.CountryId, uint64) {
+		value, ok := values[s]
+		if !ok {
+			return sgm.DefaultCountryId, uint64(sgm.DefaultCountryId)
+		}
+		bucket := overlay.bucket(value)
+		return sgm.CountryId(bucket), uint64(bucket)
+	})
+	steps := overlay.steps()
+	for _, seg := range segments {
+		bucket := int(seg.countryId)
+		style := r.styles.overlayCellStyle.With(
+			StyleOption{"fill", overlay.Ramp.Color(float64(bucket) / float64(steps-1))})
+		pathEl := g.CreatePath(style, cr.buildPath(seg))
+		pathEl.SetTitle(fmt.Sprintf("%s: %s", overlay.Title,
+			overlay.format(overlay.bucketValue(bucket))))
+	}
+}
+
+// renderOverlayLegends draws gradient bars with ranges of overlays in the
+// bottom left corner of the map
+func (r *Renderer) renderOverlayLegends() {
+	point := sgmmath.Point{X: r.innerBounds.Min.X, Y: r.innerBounds.Max.Y - overlayLegendHeight}
+	for i, overlay := range r.overlays {
+		if !overlay.rendered {
+			continue
+		}
+
+		gradientId := fmt.Sprintf("overlay-legend-%d", i)
+		r.canvas.CreateLinearGradient(gradientId, overlay.Ramp)
+
+		r.layer.CreateRect(r.styles.overlayLegendStyle.With(
+			StyleOption{"fill", fmt.Sprintf("url(#%s)", gradientId)},
+		), sgmmath.BoundingRect{
+			Min: point,
+			Max: point.Add(sgmmath.Point{X: overlayLegendWidth, Y: overlayLegendHeight}),
+		})
+
+		r.layer.CreateText(r.styles.starTextStyle, point.Add(sgmmath.Point{Y: -r.styles.fontSize / 2}),
+			overlay.Title)
+		r.layer.CreateText(r.styles.battleTextStyle,
+			point.Add(sgmmath.Point{Y: overlayLegendHeight + r.styles.fontSize}),
+			overlay.format(overlay.min))
+		r.layer.CreateText(r.styles.battleTextStyle.With(StyleOption{"text-anchor", "end"}),
+			point.Add(sgmmath.Point{X: overlayLegendWidth, Y: overlayLegendHeight + r.styles.fontSize}),
+			overlay.format(overlay.max))
+
+		point.Y -= overlayLegendLines * r.styles.fontSize
+	}
+}
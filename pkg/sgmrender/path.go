@@ -21,6 +21,7 @@ const (
 
 var (
 	defaultStarPath = newDiamondPath(starHalfSize)
+	chokepointPath  = newTrianglePath(2 * starbaseHalfSize)
 
 	outpostPath      = newStarbasePath(outpostHalfSize)
 	starbasePath     = newStarbasePath(starbaseHalfSize)
@@ -34,6 +35,13 @@ func newDiamondPath(size float64) Path {
 		Complete()
 }
 
+func newTrianglePath(size float64) Path {
+	return NewPath().
+		MoveTo(0.0, -size).LineTo(0.866*size, size/2).
+		LineTo(-0.866*size, size/2).
+		Complete()
+}
+
 func newStarbasePath(size float64) Path {
 	return NewPath().
 		MoveTo(-size, 0.0).LineTo(-size/2, 5*size/6).HorLine(size/2).
@@ -117,6 +125,15 @@ func (p Path) CurveDiff(points []sgmmath.Point, dx, dy float64) Path {
 		})}
 }
 
+func (p Path) QuadTo(control, point sgmmath.Point) Path {
+	return Path{path: append(p.path,
+		PathElement{
+			Command: 'Q',
+			Points:  []sgmmath.Point{control},
+			Point:   point,
+		})}
+}
+
 func (p Path) Complete() Path {
 	return Path{path: append(p.path, PathElement{Command: 'Z'})}
 }
@@ -147,7 +164,7 @@ func (p *Path) String() string {
 				fmt.Fprintf(buf, "%f", el.Y)
 			case 'Z':
 				// Z has no options
-			case 'c':
+			case 'c', 'Q':
 				for _, point := range el.Points {
 					fmt.Fprintf(buf, "%f,%f ", point.X, point.Y)
 				}
@@ -0,0 +1,322 @@
+package sgmrender
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	pointsPerMM = 72.0 / 25.4
+
+	pdfDefaultDPI     = 150.0
+	pdfMargin         = 10.0
+	pdfLegendHeight   = 14.0
+	pdfLegendGap      = 8.0
+	pdfCropMarkOffset = 1.5
+	pdfCropMarkLength = 5.0
+)
+
+// Page sizes in millimeters, portrait orientation
+var pdfPageSizes = map[string][2]float64{
+	"A0":      {841, 1189},
+	"A1":      {594, 841},
+	"A2":      {420, 594},
+	"A3":      {297, 420},
+	"A4":      {210, 297},
+	"A5":      {148, 210},
+	"LETTER":  {215.9, 279.4},
+	"LEGAL":   {215.9, 355.6},
+	"TABLOID": {279.4, 431.8},
+}
+
+// Legend is the title block of the map. Canvases which implement
+// LegendCanvas draw it in a dedicated area instead of the map corner.
+type Legend struct {
+	Title    string
+	Subtitle string
+	Date     string
+	Footer   string
+
+	// Color of the map background
+	Background string
+}
+
+type LegendCanvas interface {
+	Canvas
+	SetLegend(legend Legend)
+}
+
+type PDFOptions struct {
+	// Name of the page size like A4 or Letter, or size in millimeters
+	// like 500x700. Orientation is chosen to fit the map better.
+	PageSize string
+
+	// Split the map across several pages printed at DPI pixels of the
+	// canvas per inch. Adjacent pages share Overlap millimeters of the map.
+	Tile    bool
+	DPI     float64
+	Overlap float64
+
+	CropMarks bool
+}
+
+// ParsePageSize returns width and height of the page in millimeters
+func ParsePageSize(name string) (float64, float64, error) {
+	if size, ok := pdfPageSizes[strings.ToUpper(name)]; ok {
+		return size[0], size[1], nil
+	}
+
+	parts := strings.Split(strings.ToLower(name), "x")
+	if len(parts) == 2 {
+		w, errW := strconv.ParseFloat(parts[0], 64)
+		h, errH := strconv.ParseFloat(parts[1], 64)
+		if errW == nil && errH == nil && w > 2*pdfMargin && h > 2*pdfMargin+pdfLegendHeight+pdfLegendGap {
+			return w, h, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid page size '%s'", name)
+}
+
+// PDFCanvas draws the map as vector PDF document with embedded fonts,
+// optionally splitting it across several pages to be printed as a poster
+type PDFCanvas struct {
+	*Scene
+
+	opts   PDFOptions
+	legend Legend
+}
+
+func NewPDFCanvasFactory(opts PDFOptions) CanvasFactory {
+	return func(bounds sgmmath.BoundingRect, width, height float64) Canvas {
+		return &PDFCanvas{
+			Scene: NewScene(bounds, width, height),
+			opts:  opts,
+		}
+	}
+}
+
+func (c *PDFCanvas) SetLegend(legend Legend) {
+	c.legend = legend
+}
+
+// pdfPage is a page of the document showing area of the map. All sizes are
+// in points, y grows from top of the page to simplify layout.
+type pdfPage struct {
+	width, height float64
+
+	// Area of the page where the map is drawn
+	mapArea sgmmath.BoundingRect
+	// Area of the map shown on the page in scene units
+	viewport sgmmath.BoundingRect
+
+	row, column int
+}
+
+func (c *PDFCanvas) layoutPages() (pages []pdfPage, rows, columns int) {
+	pageW, pageH, err := ParsePageSize(c.opts.PageSize)
+	if err != nil {
+		log.Printf("warn: %s, using A4", err.Error())
+		pageW, pageH, _ = ParsePageSize("A4")
+	}
+
+	mapW, mapH := c.Bounds.Size()
+	// Map area size in points for the orientation of the page
+	areaSize := func(pageW, pageH float64) (float64, float64) {
+		return (pageW - 2*pdfMargin) * pointsPerMM,
+			(pageH - 2*pdfMargin - pdfLegendHeight - pdfLegendGap) * pointsPerMM
+	}
+
+	if !c.opts.Tile {
+		if (mapW > mapH) != (pageW > pageH) {
+			pageW, pageH = pageH, pageW
+		}
+		areaW, areaH := areaSize(pageW, pageH)
+		scale := math.Min(areaW/mapW, areaH/mapH)
+		min := sgmmath.Point{
+			X: pdfMargin*pointsPerMM + (areaW-mapW*scale)/2,
+			Y: pdfMargin*pointsPerMM + (areaH-mapH*scale)/2,
+		}
+		return []pdfPage{{
+			width:    pageW * pointsPerMM,
+			height:   pageH * pointsPerMM,
+			mapArea:  sgmmath.BoundingRect{Min: min, Max: min.Add(sgmmath.Point{X: mapW * scale, Y: mapH * scale})},
+			viewport: c.Bounds,
+		}}, 1, 1
+	}
+
+	dpi := c.opts.DPI
+	if dpi <= 0 {
+		dpi = pdfDefaultDPI
+	}
+	// Canvas width is in pixels, so compute how many points are in unit
+	scale := c.Width / mapW / dpi * 72
+	overlap := math.Max(0, c.opts.Overlap) * pointsPerMM
+
+	countTiles := func(pageW, pageH float64) (int, int) {
+		areaW, areaH := areaSize(pageW, pageH)
+		tiles := func(size, area float64) int {
+			if size <= area || area <= overlap {
+				return 1
+			}
+			return int(math.Ceil((size - overlap) / (area - overlap)))
+		}
+		return tiles(mapH*scale, areaH), tiles(mapW*scale, areaW)
+	}
+	rows, columns = countTiles(pageW, pageH)
+	if r, c := countTiles(pageH, pageW); r*c < rows*columns {
+		pageW, pageH, rows, columns = pageH, pageW, r, c
+	}
+
+	areaW, areaH := areaSize(pageW, pageH)
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			min := c.Bounds.Min.Add(sgmmath.Point{
+				X: float64(column) * (areaW - overlap) / scale,
+				Y: float64(row) * (areaH - overlap) / scale,
+			})
+			max := sgmmath.Point{
+				X: math.Min(min.X+areaW/scale, c.Bounds.Max.X),
+				Y: math.Min(min.Y+areaH/scale, c.Bounds.Max.Y),
+			}
+			areaMin := sgmmath.Point{X: pdfMargin * pointsPerMM, Y: pdfMargin * pointsPerMM}
+
+			pages = append(pages, pdfPage{
+				width:  pageW * pointsPerMM,
+				height: pageH * pointsPerMM,
+				mapArea: sgmmath.BoundingRect{
+					Min: areaMin,
+					Max: areaMin.Add(sgmmath.Point{X: (max.X - min.X) * scale, Y: (max.Y - min.Y) * scale}),
+				},
+				viewport: sgmmath.BoundingRect{Min: min, Max: max},
+				row:      row,
+				column:   column,
+			})
+		}
+	}
+	return pages, rows, columns
+}
+
+// ctm maps scene coordinates into PDF coordinates where y grows up
+func (page *pdfPage) ctm() affine {
+	vw, _ := page.viewport.Size()
+	aw, _ := page.mapArea.Size()
+	scale := aw / vw
+	return affine{
+		scale, 0, 0, -scale,
+		page.mapArea.Min.X - scale*page.viewport.Min.X,
+		page.height - page.mapArea.Min.Y + scale*page.viewport.Min.Y,
+	}
+}
+
+func (c *PDFCanvas) WriteTo(w io.Writer) (int64, error) {
+	doc := newPDFDocument()
+	pages, rows, columns := c.layoutPages()
+
+	catalogId, pagesId := doc.allocObject(), doc.allocObject()
+	pageIds := make([]string, 0, len(pages))
+	for i := range pages {
+		page := &pages[i]
+		content := newPDFContent(doc, c.Scene, sgmmath.BoundingRect{
+			Max: sgmmath.Point{X: page.width, Y: page.height},
+		})
+
+		w, h := page.mapArea.Size()
+		content.op("q %s %s %s %s re W n", pdfNum(page.mapArea.Min.X),
+			pdfNum(page.height-page.mapArea.Max.Y), pdfNum(w), pdfNum(h))
+		content.drawNode(c.sceneNode, page.ctm(), defaultRasterStyle)
+		content.op("Q")
+
+		if c.opts.CropMarks {
+			c.drawCropMarks(content, page)
+		}
+		c.drawLegend(content, page, rows, columns)
+
+		contentId, pageId := doc.allocObject(), doc.allocObject()
+		doc.writeStream(contentId, "", content.buf.Bytes())
+		doc.writeObject(pageId, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox %s /Contents %d 0 R /Resources %s >>",
+			pagesId, content.boxString(), contentId, content.resourcesDict()))
+		pageIds = append(pageIds, fmt.Sprintf("%d 0 R", pageId))
+	}
+
+	doc.writeObject(pagesId, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(pageIds, " "), len(pageIds)))
+	doc.writeObject(catalogId, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesId))
+	return doc.finish(w, catalogId)
+}
+
+// drawCropMarks draws short lines outside of corners of the map area which
+// show where to cut pages
+func (c *PDFCanvas) drawCropMarks(content *pdfContent, page *pdfPage) {
+	offset, length := pdfCropMarkOffset*pointsPerMM, pdfCropMarkLength*pointsPerMM
+	content.op("q 0 0 0 RG 0.25 w")
+	for _, x := range []float64{page.mapArea.Min.X, page.mapArea.Max.X} {
+		for _, y := range []float64{page.mapArea.Min.Y, page.mapArea.Max.Y} {
+			dx, dy := offset, offset
+			if x == page.mapArea.Min.X {
+				dx = -dx
+			}
+			if y == page.mapArea.Min.Y {
+				dy = -dy
+			}
+			sx, sy := math.Copysign(1, dx), math.Copysign(1, dy)
+
+			py := page.height - y
+			content.op("%s %s m %s %s l S", pdfNum(x+dx), pdfNum(py),
+				pdfNum(x+dx+sx*length), pdfNum(py))
+			content.op("%s %s m %s %s l S", pdfNum(x), pdfNum(py-dy),
+				pdfNum(x), pdfNum(py-dy-sy*length))
+		}
+	}
+	content.op("Q")
+}
+
+// drawLegend draws the title block in the bottom of the page, tiled pages
+// also show their position in the poster
+func (c *PDFCanvas) drawLegend(content *pdfContent, page *pdfPage, rows, columns int) {
+	left := pdfMargin * pointsPerMM
+	right := page.width - left
+	top := (pdfMargin + pdfLegendHeight) * pointsPerMM
+	titleSize, textSize := 14.0, 8.0
+
+	content.op("q 0.5 0.5 0.5 RG 0.5 w %s %s m %s %s l S Q",
+		pdfNum(left), pdfNum(top), pdfNum(right), pdfNum(top))
+
+	content.op("q 0.13 0.18 0.24 rg")
+	// Coordinates of legend text grow up like in PDF
+	textAt := func(face *fontFace, size, x, y float64, text, anchor string) {
+		if text != "" {
+			content.showText(face, size, affine{1, 0, 0, -1, 0, page.height},
+				sgmmath.Point{X: x, Y: page.height - y}, text, anchor, 0)
+		}
+	}
+
+	titleY := top - titleSize - 2
+	textAt(fontBold, titleSize, left, titleY, c.legend.Title, "start")
+	subtitle := c.legend.Subtitle
+	if c.legend.Date != "" {
+		if subtitle != "" {
+			subtitle += " — "
+		}
+		subtitle += c.legend.Date
+	}
+	textAt(fontRegular, textSize+2, left, titleY-textSize-6, subtitle, "start")
+
+	if rows*columns > 1 {
+		textAt(fontBold, textSize, right, titleY, fmt.Sprintf(
+			"Sheet %d of %d: row %d, column %d", page.row*columns+page.column+1,
+			rows*columns, page.row+1, page.column+1), "end")
+		if c.opts.Overlap > 0 {
+			textAt(fontRegular, textSize, right, titleY-textSize-2, fmt.Sprintf(
+				"Pages overlap by %s mm", pdfNum(c.opts.Overlap)), "end")
+		}
+	}
+	textAt(fontItalic, textSize, right, titleY-2*textSize-6, c.legend.Footer, "end")
+	content.op("Q")
+}
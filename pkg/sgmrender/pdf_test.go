@@ -0,0 +1,172 @@
+package sgmrender
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// newTestState creates chain of stars connected by hyperlanes
+func newTestState() *sgm.GameState {
+	coords := [][2]float64{{0, 0}, {100, 30}, {220, 110}, {60, 140}}
+	state := &sgm.GameState{
+		Name:      "Test",
+		Date:      "2300.01.01",
+		Stars:     make(map[sgm.StarId]*sgm.Star),
+		Countries: make(map[sgm.CountryId]*sgm.Country),
+	}
+	for i, coord := range coords {
+		star := &sgm.Star{}
+		star.Coordinate.X, star.Coordinate.Y = coord[0], coord[1]
+		state.Stars[sgm.StarId(i)] = star
+	}
+	for i := 0; i+1 < len(coords); i++ {
+		from, to := state.Stars[sgm.StarId(i)], state.Stars[sgm.StarId(i+1)]
+		from.Hyperlanes = append(from.Hyperlanes, sgm.Hyperlane{ToId: sgm.StarId(i + 1), To: to})
+		to.Hyperlanes = append(to.Hyperlanes, sgm.Hyperlane{ToId: sgm.StarId(i), To: from})
+	}
+	return state
+}
+
+// renderTestPDF renders map of 2000x1000 pixels which is 508x254 mm at 100 DPI
+func renderTestPDF(t *testing.T, pdfOpts PDFOptions) []byte {
+	pdfOpts.DPI = 100
+	r := NewCanvasRenderer(newTestState(), RenderOptions{Width: 2000, Height: 1000},
+		NewPDFCanvasFactory(pdfOpts))
+	r.Render()
+
+	data, err := r.WriteToBytes()
+	assert.NoError(t, err)
+	return data
+}
+
+var (
+	pdfStartXRefRe = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pdfXRefRe      = regexp.MustCompile(`^xref\n0 (\d+)\n0000000000 65535 f \n`)
+	pdfStreamRe    = regexp.MustCompile(`^\d+ 0 obj\n<< .*/Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+// pdfObjects checks that cross-reference table points to objects and
+// returns their data
+func pdfObjects(t *testing.T, data []byte) [][]byte {
+	m := pdfStartXRefRe.FindSubmatch(data)
+	if !assert.NotNil(t, m, "startxref is not found") {
+		return nil
+	}
+	xrefOffset, _ := strconv.Atoi(string(m[1]))
+	xref := data[xrefOffset:]
+	m = pdfXRefRe.FindSubmatch(xref)
+	if !assert.NotNil(t, m, "xref is not found at %d", xrefOffset) {
+		return nil
+	}
+	count, _ := strconv.Atoi(string(m[1]))
+	xref = xref[len(m[0]):]
+
+	var objects [][]byte
+	for id := 1; id < count; id++ {
+		var offset int
+		_, err := fmt.Sscanf(string(xref[(id-1)*20:id*20]), "%010d 00000 n \n", &offset)
+		if !assert.NoError(t, err, "object %d", id) {
+			return nil
+		}
+		object := data[offset:]
+		assert.True(t, bytes.HasPrefix(object, []byte(fmt.Sprintf("%d 0 obj\n", id))),
+			"object %d at %d", id, offset)
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// pdfStreams decompresses all streams of the document
+func pdfStreams(t *testing.T, objects [][]byte) (streams [][]byte) {
+	for _, object := range objects {
+		m := pdfStreamRe.FindSubmatch(object)
+		if m == nil {
+			continue
+		}
+		length, _ := strconv.Atoi(string(m[1]))
+		zr, err := zlib.NewReader(bytes.NewReader(object[len(m[0]) : len(m[0])+length]))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		stream, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+		streams = append(streams, stream)
+	}
+	return
+}
+
+func countPDFPages(objects [][]byte) (count int) {
+	for _, object := range objects {
+		if bytes.Contains(object[:bytes.Index(object, []byte("\n"))+20], []byte("<< /Type /Page ")) {
+			count++
+		}
+	}
+	return
+}
+
+func TestPDFTiling(t *testing.T) {
+	for _, tc := range []struct {
+		opts  PDFOptions
+		pages int
+	}{
+		{PDFOptions{PageSize: "A4"}, 1},
+		// Portrait map area of A4 is 190x255 mm, 3 columns of it fit map
+		// with 10 mm overlaps, landscape pages would need 2x2 grid
+		{PDFOptions{PageSize: "A4", Tile: true, Overlap: 10}, 3},
+		// Map is 254 mm high, so with 0 mm overlap a row of 190 mm is
+		// not enough and 3 columns are still needed
+		{PDFOptions{PageSize: "A4", Tile: true}, 3},
+		// Portrait map area of A3 is 277x375 mm
+		{PDFOptions{PageSize: "A3", Tile: true, Overlap: 10}, 2},
+		{PDFOptions{PageSize: "600x400", Tile: true, Overlap: 10}, 1},
+	} {
+		name := fmt.Sprintf("%s tile=%v overlap=%v", tc.opts.PageSize, tc.opts.Tile, tc.opts.Overlap)
+		objects := pdfObjects(t, renderTestPDF(t, tc.opts))
+		assert.Equal(t, tc.pages, countPDFPages(objects), name)
+	}
+}
+
+func TestPDFTileOverlap(t *testing.T) {
+	bounds := sgmmath.BoundingRect{Max: sgmmath.Point{X: 1000, Y: 500}}
+	c := NewPDFCanvasFactory(PDFOptions{PageSize: "A4", Tile: true, DPI: 100, Overlap: 10})(
+		bounds, 2000, 1000).(*PDFCanvas)
+	pages, rows, columns := c.layoutPages()
+	if !assert.Len(t, pages, 3) {
+		return
+	}
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, 3, columns)
+
+	// 10 mm of the map at 100 DPI is 39.37 pixels, each pixel is half of
+	// the scene unit
+	for i := 1; i < len(pages); i++ {
+		assert.InDelta(t, 10/25.4*100/2, pages[i-1].viewport.Max.X-pages[i].viewport.Min.X, 1e-6)
+		assert.Equal(t, i, pages[i].column)
+	}
+	assert.Equal(t, c.Bounds.Max.X, pages[len(pages)-1].viewport.Max.X)
+}
+
+func TestPDFCropMarks(t *testing.T) {
+	countLines := func(opts PDFOptions) (count int) {
+		for _, stream := range pdfStreams(t, pdfObjects(t, renderTestPDF(t, opts))) {
+			count += bytes.Count(stream, []byte(" l S\n"))
+		}
+		return
+	}
+
+	opts := PDFOptions{PageSize: "A4", Tile: true, Overlap: 10}
+	withoutMarks := countLines(opts)
+	opts.CropMarks = true
+	// Two lines in each corner of 3 pages
+	assert.Equal(t, withoutMarks+3*4*2, countLines(opts))
+}
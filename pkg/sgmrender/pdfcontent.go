@@ -0,0 +1,346 @@
+package sgmrender
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// pdfContent is a content stream of page, form or pattern. Scene elements
+// are written in coordinates of the stream, so transforms are applied to
+// paths instead of using cm operator.
+type pdfContent struct {
+	doc   *pdfDocument
+	scene *Scene
+	buf   bytes.Buffer
+
+	// Media box of the page used as bounding box of transparency groups
+	box sgmmath.BoundingRect
+
+	resources map[string]struct{}
+}
+
+func newPDFContent(doc *pdfDocument, scene *Scene, box sgmmath.BoundingRect) *pdfContent {
+	return &pdfContent{doc: doc, scene: scene, box: box, resources: make(map[string]struct{})}
+}
+
+func (c *pdfContent) newContent() *pdfContent {
+	return newPDFContent(c.doc, c.scene, c.box)
+}
+
+// use marks resource as used by the stream and returns its name
+func (c *pdfContent) use(name string) string {
+	c.resources[name] = struct{}{}
+	return name
+}
+
+func (c *pdfContent) resourcesDict() string {
+	return c.doc.resourcesDict(c.resources)
+}
+
+func (c *pdfContent) op(format string, args ...interface{}) {
+	fmt.Fprintf(&c.buf, format, args...)
+	c.buf.WriteByte('\n')
+}
+
+func (c *pdfContent) drawNode(n *sceneNode, ctm affine, rs rasterStyle) {
+	ctm = ctm.mul(n.transform)
+	rs = rs.inherit(n.style)
+	opacity := parseStyleFloat(n.style.Get("opacity"), 1.0)
+	if opacity <= 0 {
+		return
+	}
+
+	switch n.kind {
+	case sceneNodeGroup:
+		blend := n.style.Get("mix-blend-mode")
+		if opacity >= 1.0 && (blend == "" || blend == "normal") {
+			for _, child := range n.children {
+				c.drawNode(child, ctm, rs)
+			}
+			return
+		}
+
+		// Opacity applies to group as a whole, so it is drawn as
+		// transparency group
+		form := c.newContent()
+		for _, child := range n.children {
+			form.drawNode(child, ctm, rs)
+		}
+		formName := c.use(c.doc.writeForm(form))
+		c.op("q /%s gs /%s Do Q", c.use(c.doc.extGState(opacity, opacity, blend)), formName)
+	case sceneNodeShape:
+		c.drawOutline(n.outline, ctm, rs, opacity)
+	case sceneNodeText:
+		c.drawText(n, ctm, rs, opacity)
+	}
+}
+
+func (c *pdfContent) drawOutline(o outline, ctm affine, rs rasterStyle, opacity float64) {
+	if len(o) == 0 {
+		return
+	}
+
+	devOutline := o.transform(ctm)
+	c.op("q")
+	fill := rs.fill != "none" && c.setPaint(rs.fill, ctm, devOutline, false)
+	stroke := rs.stroke != "none" && rs.strokeWidth > 0 && c.setPaint(rs.stroke, ctm, devOutline, true)
+	if !fill && !stroke {
+		c.op("Q")
+		return
+	}
+
+	c.op("/%s gs", c.use(c.doc.extGState(rs.fillOpacity*opacity, rs.strokeOpacity*opacity, "")))
+	if stroke {
+		c.setStrokeStyle(rs, ctm.scale())
+	}
+	for _, op := range devOutline {
+		switch op.cmd {
+		case 'M':
+			c.op("%s %s m", pdfNum(op.pts[0].X), pdfNum(op.pts[0].Y))
+		case 'L':
+			c.op("%s %s l", pdfNum(op.pts[0].X), pdfNum(op.pts[0].Y))
+		case 'C':
+			c.op("%s %s %s %s %s %s c",
+				pdfNum(op.pts[0].X), pdfNum(op.pts[0].Y),
+				pdfNum(op.pts[1].X), pdfNum(op.pts[1].Y),
+				pdfNum(op.pts[2].X), pdfNum(op.pts[2].Y))
+		case 'Z':
+			c.op("h")
+		}
+	}
+
+	paintOp := "S"
+	if fill {
+		paintOp = "f"
+		if stroke {
+			paintOp = "B"
+		}
+		if rs.evenOdd {
+			paintOp += "*"
+		}
+	}
+	c.op("%s Q", paintOp)
+}
+
+func (c *pdfContent) setStrokeStyle(rs rasterStyle, scale float64) {
+	c.op("%s w", pdfNum(rs.strokeWidth*scale))
+	switch rs.lineCap {
+	case "round":
+		c.op("1 J")
+	case "square":
+		c.op("2 J")
+	}
+	switch rs.lineJoin {
+	case "round":
+		c.op("1 j")
+	case "bevel":
+		c.op("2 j")
+	default:
+		c.op("%s M", pdfNum(rs.miterLimit))
+	}
+
+	if len(rs.dashArray) > 0 {
+		dashes := make([]string, len(rs.dashArray))
+		for i, dash := range rs.dashArray {
+			dashes[i] = pdfNum(dash * scale)
+		}
+		c.op("[%s] %s d", strings.Join(dashes, " "), pdfNum(rs.dashOffset*scale))
+	}
+}
+
+// setPaint sets fill or stroke color, returns false if there is nothing to
+// paint with
+func (c *pdfContent) setPaint(value string, ctm affine, devOutline outline, stroke bool) bool {
+	colorOp, patternOp, csOp := "rg", "scn", "cs"
+	if stroke {
+		colorOp, patternOp, csOp = "RG", "SCN", "CS"
+	}
+
+	if !strings.HasPrefix(value, "url(#") {
+		color, ok := parseColor(value, 1.0)
+		if !ok {
+			return false
+		}
+		c.op("%s %s %s %s", pdfNum(float64(color[0])), pdfNum(float64(color[1])),
+			pdfNum(float64(color[2])), colorOp)
+		return true
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(value, "url(#"), ")")
+	var name string
+	if colors, ok := c.scene.gradients[id]; ok {
+		name = c.doc.writeGradient(colors, devOutline)
+	} else if pattern, ok := c.scene.patterns[id]; ok {
+		name = c.doc.writePattern(c, id, pattern, ctm)
+	}
+	if name == "" {
+		return false
+	}
+	c.op("/Pattern %s /%s %s", csOp, c.use(name), patternOp)
+	return true
+}
+
+func (c *pdfContent) drawText(n *sceneNode, ctm affine, rs rasterStyle, opacity float64) {
+	face := selectFontFace(rs.fontWeight, rs.fontStyle, rs.fontVariant)
+	c.op("q")
+	fill := rs.fill != "none" && c.setPaint(rs.fill, ctm, nil, false)
+	stroke := rs.stroke != "none" && rs.strokeWidth > 0 && c.setPaint(rs.stroke, ctm, nil, true)
+	if !fill && !stroke {
+		c.op("Q")
+		return
+	}
+
+	mode := 0
+	if stroke {
+		mode = 1
+		if fill {
+			mode = 2
+		}
+	}
+
+	c.op("/%s gs", c.use(c.doc.extGState(rs.fillOpacity*opacity, rs.strokeOpacity*opacity, "")))
+	if stroke {
+		c.setStrokeStyle(rs, ctm.scale())
+	}
+	c.showText(face, rs.fontSize, ctm, n.point, n.text, rs.textAnchor, mode)
+	c.op("Q")
+}
+
+// showText writes text object, text space is flipped as scene coordinates
+// grow down
+func (c *pdfContent) showText(face *fontFace, size float64, ctm affine,
+	point sgmmath.Point, text, anchor string, mode int,
+) {
+	f := c.doc.font(face)
+	var buf sfnt.Buffer
+	indices, offsets, width := face.glyphs(&buf, text)
+	if len(indices) == 0 {
+		return
+	}
+	switch anchor {
+	case "middle":
+		point.X -= width * size / 2
+	case "end":
+		point.X -= width * size
+	}
+
+	runes := []rune(text)
+	var tj strings.Builder
+	tj.WriteString("[<")
+	for i, idx := range indices {
+		if i > 0 {
+			// Adjust position by kerning which is not known to viewer
+			adjust := offsets[i] - offsets[i-1] - face.advance(&buf, indices[i-1])
+			if adjust > 1e-4 || adjust < -1e-4 {
+				fmt.Fprintf(&tj, "> %s <", pdfNum(-adjust*1000))
+			}
+		}
+		fmt.Fprintf(&tj, "%04X", int(idx))
+		if _, ok := f.glyphs[idx]; !ok && i < len(runes) {
+			f.glyphs[idx] = runes[i]
+		}
+	}
+	tj.WriteString(">] TJ")
+
+	c.use(f.name)
+	tm := ctm.mul(affine{1, 0, 0, -1, point.X, point.Y})
+	c.op("BT /%s %s Tf %d Tr %s %s %s %s %s %s Tm %s ET", f.name, pdfNum(size), mode,
+		pdfNum(tm[0]), pdfNum(tm[1]), pdfNum(tm[2]), pdfNum(tm[3]), pdfNum(tm[4]), pdfNum(tm[5]),
+		tj.String())
+}
+
+func (c *pdfContent) boxString() string {
+	return fmt.Sprintf("[%s %s %s %s]", pdfNum(c.box.Min.X), pdfNum(c.box.Min.Y),
+		pdfNum(c.box.Max.X), pdfNum(c.box.Max.Y))
+}
+
+// writeForm writes content as transparency group XObject
+func (doc *pdfDocument) writeForm(form *pdfContent) string {
+	id := doc.allocObject()
+	doc.writeStream(id, fmt.Sprintf(
+		"/Type /XObject /Subtype /Form /BBox %s /Group << /S /Transparency >> /Resources %s",
+		form.boxString(), form.resourcesDict()), form.buf.Bytes())
+	return doc.addResource("XObject", "X", fmt.Sprintf("%d 0 R", id))
+}
+
+// writePattern writes tiling pattern for the transformation where it is
+// used as pattern space has to be mapped to default coordinates of the page
+func (doc *pdfDocument) writePattern(c *pdfContent, id string, pattern *scenePattern, ctm affine) string {
+	if pattern.size <= 0 {
+		return ""
+	}
+	key := fmt.Sprintf("%s %v", id, ctm)
+	if name, ok := doc.patterns[key]; ok {
+		return name
+	}
+
+	tile := c.newContent()
+	tile.drawNode(pattern.root, identityAffine, defaultRasterStyle)
+
+	objId := doc.allocObject()
+	size := pdfNum(pattern.size)
+	doc.writeStream(objId, fmt.Sprintf(
+		"/Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1 /BBox [0 0 %s %s] "+
+			"/XStep %s /YStep %s /Matrix [%s %s %s %s %s %s] /Resources %s",
+		size, size, size, size,
+		pdfNum(ctm[0]), pdfNum(ctm[1]), pdfNum(ctm[2]), pdfNum(ctm[3]), pdfNum(ctm[4]), pdfNum(ctm[5]),
+		tile.resourcesDict()), tile.buf.Bytes())
+
+	name := doc.addResource("Pattern", "P", fmt.Sprintf("%d 0 R", objId))
+	doc.patterns[key] = name
+	return name
+}
+
+// writeGradient writes horizontal axial shading over bounding box of the
+// shape like SVG linear gradient does by default
+func (doc *pdfDocument) writeGradient(colors []string, devOutline outline) string {
+	stops := make([]rgba, 0, len(colors))
+	for _, color := range colors {
+		if c, ok := parseColor(color, 1.0); ok {
+			stops = append(stops, c)
+		}
+	}
+	if len(stops) == 0 {
+		return ""
+	}
+	if len(stops) == 1 {
+		stops = append(stops, stops[0])
+	}
+
+	bounds := sgmmath.NewBoundingRect()
+	for _, op := range devOutline {
+		if op.cmd != 'Z' {
+			bounds.Add(op.pts[0])
+		}
+	}
+	if bounds.IsZero() {
+		return ""
+	}
+
+	rgb := func(c rgba) string {
+		return fmt.Sprintf("[%s %s %s]", pdfNum(float64(c[0])), pdfNum(float64(c[1])), pdfNum(float64(c[2])))
+	}
+	var functions, boundsArr, encode []string
+	for i := 1; i < len(stops); i++ {
+		functions = append(functions, fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 %s /C1 %s /N 1 >>",
+			rgb(stops[i-1]), rgb(stops[i])))
+		if i < len(stops)-1 {
+			boundsArr = append(boundsArr, pdfNum(float64(i)/float64(len(stops)-1)))
+		}
+		encode = append(encode, "0 1")
+	}
+
+	objId := doc.allocObject()
+	doc.writeObject(objId, fmt.Sprintf(
+		"<< /Type /Pattern /PatternType 2 /Shading << /ShadingType 2 /ColorSpace /DeviceRGB "+
+			"/Coords [%s %s %s %s] /Extend [true true] /Function << /FunctionType 3 /Domain [0 1] "+
+			"/Functions [%s] /Bounds [%s] /Encode [%s] >> >> >>",
+		pdfNum(bounds.Min.X), pdfNum(bounds.Min.Y), pdfNum(bounds.Max.X), pdfNum(bounds.Min.Y),
+		strings.Join(functions, " "), strings.Join(boundsArr, " "), strings.Join(encode, " ")))
+	return doc.addResource("Pattern", "P", fmt.Sprintf("%d 0 R", objId))
+}
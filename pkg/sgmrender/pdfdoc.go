@@ -0,0 +1,248 @@
+package sgmrender
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfDocument writes objects of PDF file. Resources such as fonts and
+// patterns are shared between content streams, every stream lists only
+// resources it uses, so they don't refer to themselves.
+type pdfDocument struct {
+	buf     bytes.Buffer
+	offsets []int
+
+	resources map[string]pdfResource
+	gstates   map[string]string
+	patterns  map[string]string
+	fonts     map[*fontFace]*pdfFont
+	fontList  []*pdfFont
+	nextName  int
+}
+
+type pdfResource struct {
+	category string
+	value    string
+}
+
+type pdfFont struct {
+	face   *fontFace
+	id     int
+	name   string
+	glyphs map[sfnt.GlyphIndex]rune
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{
+		resources: make(map[string]pdfResource),
+		gstates:   make(map[string]string),
+		patterns:  make(map[string]string),
+		fonts:     make(map[*fontFace]*pdfFont),
+	}
+	doc.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return doc
+}
+
+func (doc *pdfDocument) allocObject() int {
+	doc.offsets = append(doc.offsets, 0)
+	return len(doc.offsets)
+}
+
+func (doc *pdfDocument) writeObject(id int, body string) {
+	doc.offsets[id-1] = doc.buf.Len()
+	fmt.Fprintf(&doc.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// writeStream writes compressed stream object, dict contains entries of
+// stream dictionary without length and filter
+func (doc *pdfDocument) writeStream(id int, dict string, data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	doc.offsets[id-1] = doc.buf.Len()
+	fmt.Fprintf(&doc.buf, "%d 0 obj\n<< %s /Length %d /Filter /FlateDecode >>\nstream\n",
+		id, dict, compressed.Len())
+	doc.buf.Write(compressed.Bytes())
+	doc.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// addResource registers object which can be used by content streams under
+// a new name
+func (doc *pdfDocument) addResource(category, prefix, value string) string {
+	doc.nextName++
+	name := fmt.Sprintf("%s%d", prefix, doc.nextName)
+	doc.resources[name] = pdfResource{category: category, value: value}
+	return name
+}
+
+// resourcesDict builds resources dictionary of content stream which uses
+// resources with specified names
+func (doc *pdfDocument) resourcesDict(names map[string]struct{}) string {
+	entries := make(map[string][]string)
+	for name := range names {
+		res := doc.resources[name]
+		entries[res.category] = append(entries[res.category], fmt.Sprintf("/%s %s", name, res.value))
+	}
+
+	var dict strings.Builder
+	dict.WriteString("<< /ProcSet [/PDF /Text]")
+	categories := make([]string, 0, len(entries))
+	for category := range entries {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		sort.Strings(entries[category])
+		fmt.Fprintf(&dict, " /%s << %s >>", category, strings.Join(entries[category], " "))
+	}
+	dict.WriteString(" >>")
+	return dict.String()
+}
+
+// extGState returns name of graphics state with specified alpha constants
+// and blend mode
+func (doc *pdfDocument) extGState(fillAlpha, strokeAlpha float64, blend string) string {
+	mode := "Normal"
+	switch blend {
+	case "multiply":
+		mode = "Multiply"
+	case "screen":
+		mode = "Screen"
+	case "overlay":
+		mode = "Overlay"
+	}
+
+	dict := fmt.Sprintf("<< /ca %s /CA %s /BM /%s >>", pdfNum(fillAlpha), pdfNum(strokeAlpha), mode)
+	name, ok := doc.gstates[dict]
+	if !ok {
+		name = doc.addResource("ExtGState", "GS", dict)
+		doc.gstates[dict] = name
+	}
+	return name
+}
+
+func (doc *pdfDocument) font(face *fontFace) *pdfFont {
+	f, ok := doc.fonts[face]
+	if !ok {
+		f = &pdfFont{face: face, glyphs: make(map[sfnt.GlyphIndex]rune)}
+		f.id = doc.allocObject()
+		f.name = doc.addResource("Font", "F", fmt.Sprintf("%d 0 R", f.id))
+		doc.fonts[face] = f
+		doc.fontList = append(doc.fontList, f)
+	}
+	return f
+}
+
+// finish writes shared objects and cross-reference table
+func (doc *pdfDocument) finish(w io.Writer, rootId int) (int64, error) {
+	for _, f := range doc.fontList {
+		doc.writeFont(f)
+	}
+
+	xrefOffset := doc.buf.Len()
+	fmt.Fprintf(&doc.buf, "xref\n0 %d\n0000000000 65535 f \n", len(doc.offsets)+1)
+	for _, offset := range doc.offsets {
+		fmt.Fprintf(&doc.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(doc.offsets)+1, rootId, xrefOffset)
+	return doc.buf.WriteTo(w)
+}
+
+// writeFont embeds TrueType font as CID font, so any glyph can be shown
+// using its index
+func (doc *pdfDocument) writeFont(f *pdfFont) {
+	sf := f.face.sfnt()
+	var buf sfnt.Buffer
+	ppem := fixed.Int26_6(1000 << 6)
+	units := func(v fixed.Int26_6) int {
+		return int(v) >> 6
+	}
+
+	metrics, _ := sf.Metrics(&buf, ppem, font.HintingNone)
+	bounds, _ := sf.Bounds(&buf, ppem, font.HintingNone)
+
+	glyphIds := make([]int, 0, len(f.glyphs))
+	for idx := range f.glyphs {
+		glyphIds = append(glyphIds, int(idx))
+	}
+	sort.Ints(glyphIds)
+
+	var widths, toUnicode strings.Builder
+	for _, idx := range glyphIds {
+		advance, _ := sf.GlyphAdvance(&buf, sfnt.GlyphIndex(idx), ppem, font.HintingNone)
+		fmt.Fprintf(&widths, "%d [%d] ", idx, units(advance))
+
+		var unicode strings.Builder
+		for _, r := range utf16.Encode([]rune{f.glyphs[sfnt.GlyphIndex(idx)]}) {
+			fmt.Fprintf(&unicode, "%04X", r)
+		}
+		fmt.Fprintf(&toUnicode, "<%04X> <%s>\n", idx, unicode.String())
+	}
+
+	fileId, descriptorId := doc.allocObject(), doc.allocObject()
+	cidFontId, cmapId := doc.allocObject(), doc.allocObject()
+
+	doc.writeStream(fileId, fmt.Sprintf("/Length1 %d", len(f.face.TTF)), f.face.TTF)
+
+	flags := 32
+	italicAngle := 0
+	if f.face == fontItalic || f.face == fontBoldItalic {
+		flags |= 64
+		italicAngle = -10
+	}
+	doc.writeObject(descriptorId, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] "+
+			"/ItalicAngle %d /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.face.Name, flags,
+		units(bounds.Min.X), -units(bounds.Max.Y), units(bounds.Max.X), -units(bounds.Min.Y),
+		italicAngle, units(metrics.Ascent), -units(metrics.Descent), units(metrics.CapHeight),
+		fileId))
+
+	doc.writeObject(cidFontId, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+		f.face.Name, descriptorId, widths.String()))
+
+	cmap := "/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n"
+	lines := strings.SplitAfter(toUnicode.String(), "\n")
+	for start := 0; start < len(glyphIds); start += 100 {
+		end := start + 100
+		if end > len(glyphIds) {
+			end = len(glyphIds)
+		}
+		cmap += fmt.Sprintf("%d beginbfchar\n%sendbfchar\n", end-start, strings.Join(lines[start:end], ""))
+	}
+	cmap += "endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n"
+	doc.writeStream(cmapId, "", []byte(cmap))
+
+	doc.writeObject(f.id, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.face.Name, cidFontId, cmapId))
+}
+
+func pdfNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" || s == "-0" {
+		return "0"
+	}
+	return s
+}
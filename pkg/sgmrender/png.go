@@ -0,0 +1,51 @@
+package sgmrender
+
+import (
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// PNGCanvas draws the map as PNG image using built-in rasterizer
+type PNGCanvas struct {
+	*Scene
+
+	height int
+}
+
+// NewPNGCanvasFactory creates factory of PNG canvases with images of
+// specified height in pixels. Zero height keeps the size of SVG images.
+func NewPNGCanvasFactory(height int) CanvasFactory {
+	return func(bounds sgmmath.BoundingRect, width, canvasHeight float64) Canvas {
+		c := &PNGCanvas{
+			Scene:  NewScene(bounds, width, canvasHeight),
+			height: height,
+		}
+		if c.height <= 0 {
+			c.height = int(math.Round(canvasHeight))
+		}
+		return c
+	}
+}
+
+func (c *PNGCanvas) WriteTo(w io.Writer) (int64, error) {
+	width := int(math.Round(c.Width / c.Height * float64(c.height)))
+	img := c.Rasterize(c.Bounds, width, c.height)
+
+	cw := &countingWriter{w: w}
+	err := png.Encode(cw, img)
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,138 @@
+package sgmrender
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const rasterTestSize = 32
+
+var (
+	rasterFillStyle = NewStyle(
+		StyleOption{"fill", "#ff0000"},
+		StyleOption{"stroke", "none"},
+	)
+	rasterStrokeStyle = NewStyle(
+		StyleOption{"fill", "none"},
+		StyleOption{"stroke", "#ff0000"},
+		StyleOption{"stroke-width", "6"},
+	)
+)
+
+// rasterizeTestScene draws scene of 32x32 units on the image of the same
+// size in pixels
+func rasterizeTestScene(draw func(s *Scene)) *image.RGBA {
+	bounds := sgmmath.BoundingRect{Max: sgmmath.Point{X: rasterTestSize, Y: rasterTestSize}}
+	s := NewScene(bounds, rasterTestSize, rasterTestSize)
+	draw(s)
+	return s.Rasterize(bounds, rasterTestSize, rasterTestSize)
+}
+
+func alphaAt(img *image.RGBA, x, y int) uint8 {
+	return img.RGBAAt(x, y).A
+}
+
+// coverage returns area of shapes in pixels
+func coverage(img *image.RGBA) float64 {
+	total := 0.0
+	for y := 0; y < rasterTestSize; y++ {
+		for x := 0; x < rasterTestSize; x++ {
+			total += float64(alphaAt(img, x, y)) / 255
+		}
+	}
+	return total
+}
+
+func TestRasterizeRect(t *testing.T) {
+	img := rasterizeTestScene(func(s *Scene) {
+		s.CreateRect(rasterFillStyle, sgmmath.BoundingRect{
+			Min: sgmmath.Point{X: 8.5, Y: 8},
+			Max: sgmmath.Point{X: 24, Y: 24},
+		})
+	})
+
+	assert.Equal(t, uint8(255), alphaAt(img, 16, 16))
+	assert.Equal(t, uint8(255), img.RGBAAt(16, 16).R)
+	assert.Equal(t, uint8(0), alphaAt(img, 4, 4))
+	assert.Equal(t, uint8(0), alphaAt(img, 24, 16))
+
+	// Left edge covers half of the pixel
+	assert.InDelta(t, 128, int(alphaAt(img, 8, 16)), 2)
+	assert.InDelta(t, 15.5*16, coverage(img), 0.5)
+}
+
+func TestRasterizeCircle(t *testing.T) {
+	img := rasterizeTestScene(func(s *Scene) {
+		s.CreateCircle(rasterFillStyle, sgmmath.Point{X: 16, Y: 16}, 10)
+	})
+
+	assert.Equal(t, uint8(255), alphaAt(img, 16, 16))
+	assert.Equal(t, uint8(0), alphaAt(img, 2, 2))
+	assert.Equal(t, uint8(0), alphaAt(img, 16, 27))
+	assert.InDelta(t, math.Pi*100, coverage(img), math.Pi*100*0.02)
+
+	// Edge pixels are antialiased
+	edge := alphaAt(img, 6, 16)
+	assert.True(t, edge > 0 && edge < 255, "alpha on the edge is %d", edge)
+}
+
+func TestRasterizeStrokeCaps(t *testing.T) {
+	for _, tc := range []struct {
+		lineCap string
+		// Pixel which is covered only by the cap
+		capCovered bool
+		area       float64
+	}{
+		{"butt", false, 20 * 6},
+		{"square", true, 26 * 6},
+		{"round", true, 20*6 + math.Pi*9},
+	} {
+		img := rasterizeTestScene(func(s *Scene) {
+			style := rasterStrokeStyle.With(StyleOption{"stroke-linecap", tc.lineCap})
+			s.CreatePath(style, NewPath().MoveTo(6, 16).LineTo(26, 16))
+		})
+
+		assert.Equal(t, uint8(255), alphaAt(img, 16, 14), tc.lineCap)
+		assert.Equal(t, uint8(0), alphaAt(img, 16, 20), tc.lineCap)
+		if tc.capCovered {
+			assert.Equal(t, uint8(255), alphaAt(img, 4, 16), tc.lineCap)
+		} else {
+			assert.Equal(t, uint8(0), alphaAt(img, 4, 16), tc.lineCap)
+		}
+		assert.InDelta(t, tc.area, coverage(img), tc.area*0.03, tc.lineCap)
+	}
+}
+
+func TestRasterizeStrokeJoins(t *testing.T) {
+	for _, tc := range []struct {
+		lineJoin string
+		// Pixel in the outer corner of the turn is covered only by miter
+		cornerCovered bool
+	}{
+		{"miter", true},
+		{"bevel", false},
+		{"round", false},
+	} {
+		img := rasterizeTestScene(func(s *Scene) {
+			style := rasterStrokeStyle.With(StyleOption{"stroke-linejoin", tc.lineJoin})
+			s.CreatePath(style, NewPath().MoveTo(4, 6).LineTo(24, 6).LineTo(24, 28))
+		})
+
+		// Inner sides of both segments and the join
+		assert.Equal(t, uint8(255), alphaAt(img, 10, 6), tc.lineJoin)
+		assert.Equal(t, uint8(255), alphaAt(img, 24, 20), tc.lineJoin)
+		assert.Equal(t, uint8(255), alphaAt(img, 24, 4), tc.lineJoin)
+
+		corner := alphaAt(img, 26, 3)
+		if tc.cornerCovered {
+			assert.Equal(t, uint8(255), corner, tc.lineJoin)
+		} else {
+			assert.True(t, corner < 64, "%s: alpha in the corner is %d", tc.lineJoin, corner)
+		}
+	}
+}
@@ -1,11 +1,10 @@
 package sgmrender
 
 import (
+	"bytes"
 	"embed"
 	"fmt"
-	"log"
-
-	"github.com/beevik/etree"
+	"os"
 
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
@@ -18,14 +17,9 @@ const (
 	canvasPadding = 40
 	canvasSize    = 2160
 
-	fontSize         = 2.0
 	maxCellSize      = 48.0
 	countryBorderGap = 1.2
 
-	iconSizeSm  = 4.0
-	iconStepSm  = 3.6
-	iconSizeMd  = 5.6
-	iconStepMd  = 4.8
 	svgIconSize = 16
 
 	gridSplit = 16
@@ -48,114 +42,216 @@ type RenderOptions struct {
 	NoStarSystems        bool `json:"no_star_systems"`
 	NoHyperLanes         bool `json:"no_hyperlanes"`
 	NoFleets             bool `json:"no_fleets"`
-
-	ShowSectors bool `json:"show_sectors"`
+	NoMonsters           bool `json:"no_monsters"`
+
+	ShowSectors     bool `json:"show_sectors"`
+	ShowPlayerNames bool `json:"show_player_names"`
+	ShowHumans      bool `json:"show_humans"`
+	ShowBypassLinks bool `json:"show_bypass_links"`
+	NoAnimation     bool `json:"no_animation"`
+
+	// Add tooltips, ids and data-* attributes of stars and countries, and
+	// controls toggling layers
+	Interactive bool `json:"interactive"`
+
+	// Name of the built-in theme, see BuiltinThemeNames(). CustomTheme
+	// takes precedence over it.
+	Theme       string `json:"theme"`
+	CustomTheme *Theme `json:"-"`
+
+	// Directory of SVG icons which replace or extend embedded icons, see
+	// IconNames()
+	IconDir string `json:"-"`
+
+	// Recolor countries so neighbors are distinguishable, see
+	// CountryColorModes()
+	CountryColors string `json:"country_colors"`
+
+	// Crop the map to Viewport in galaxy coordinates of the save game (min
+	// x, min y, max x, max y), to systems of ViewportCountries, or to
+	// ViewportRadius around ViewportStar. ViewportPadding is added around
+	// the area, canvasPadding if zero.
+	Viewport          []float64       `json:"viewport"`
+	ViewportCountries []sgm.CountryId `json:"viewport_countries"`
+	ViewportStar      sgm.StarId      `json:"viewport_star"`
+	ViewportRadius    float64         `json:"viewport_radius"`
+	ViewportPadding   float64         `json:"viewport_padding"`
+
+	// Size of the map image. If only one of them is set, the other one
+	// follows aspect ratio of the map, if both are set the viewport is
+	// extended to fit them. Height is 2160 if neither is set.
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+
+	// Names of built-in metric overlays, see BuiltinOverlayNames()
+	Overlays []string `json:"overlays"`
+
+	// Star systems of the route to be highlighted, in order of travel
+	Route []sgm.StarId `json:"route"`
+
+	// Mark chokepoints of ChokepointCountry which don't have bastions
+	ShowChokepoints   bool          `json:"show_chokepoints"`
+	ChokepointCountry sgm.CountryId `json:"chokepoint_country"`
+
+	// Overlay heatmap of hostile fleet power reaching systems of ThreatCountry
+	ShowThreats    bool          `json:"show_threats"`
+	ThreatCountry  sgm.CountryId `json:"threat_country"`
+	ThreatJumps    int           `json:"threat_jumps"`
+	ThreatWeighted bool          `json:"threat_weighted"`
+
+	// Shade systems by number of jumps from DistanceSources (capitals if
+	// empty) and draw contour rings every DistanceRingStep jumps, 5 if zero
+	ShowDistances    bool         `json:"show_distances"`
+	DistanceSources  []sgm.StarId `json:"distance_sources"`
+	DistanceBypasses bool         `json:"distance_bypasses"`
+	DistanceRingStep int          `json:"distance_ring_step"`
+
+	// Draw every battle instead of the most recent one in each system.
+	// Battles can be limited to BattleWars and to years between
+	// BattleFromYear and BattleToYear, zero means no limit.
+	BattleHistory  bool        `json:"battle_history"`
+	BattleWars     []sgm.WarId `json:"battle_wars"`
+	BattleFromYear int         `json:"battle_from_year"`
+	BattleToYear   int         `json:"battle_to_year"`
+
+	// Recolor the map into sides of the War, draw its front lines and
+	// summary panel
+	ShowWar bool      `json:"show_war"`
+	War     sgm.WarId `json:"war"`
+
+	// Render only what the country PointOfView knows about the galaxy
+	FogOfWar    bool          `json:"fog_of_war"`
+	PointOfView sgm.CountryId `json:"point_of_view"`
 }
 
 type Renderer struct {
 	state *sgm.GameState
 	opts  RenderOptions
 
-	doc    *etree.Document
-	canvas *etree.Element
-	defs   *etree.Element
+	styles *styles
+
+	canvas Canvas
+	layers map[string]Group
+	layer  Group
 
 	bounds       sgmmath.BoundingRect
 	innerBounds  sgmmath.BoundingRect
+	galaxyBounds sgmmath.BoundingRect
 	starGeoIndex StarGeoIndex
-
-	iconCache map[string]*etree.Document
+	visibility   *sgm.Visibility
+
+	war             *sgm.War
+	countryRenderer *countryRenderer
+	overlays        []*MetricOverlay
+	distanceJumps   map[*sgm.Star]int
+	countrySystems  map[sgm.CountryId]int
+	countryColors   map[sgm.CountryId]countryColorPair
 }
 
 func NewRenderer(state *sgm.GameState, opts RenderOptions) *Renderer {
-	r := &Renderer{state: state, opts: opts}
-	r.computeBounds()
-	r.buildStarIndex()
-
-	r.doc = etree.NewDocument()
-	r.doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
-
-	w, h := r.bounds.Size()
-	r.canvas = r.doc.CreateElement("svg")
-	r.canvas.CreateAttr("xmlns", "http://www.w3.org/2000/svg")
-	r.canvas.CreateAttr("width", fmt.Sprint(w/h*canvasSize))
-	r.canvas.CreateAttr("height", fmt.Sprint(canvasSize))
-	r.canvas.CreateAttr("viewBox",
-		fmt.Sprintf("%f %f %f %f",
-			r.bounds.Min.X, r.bounds.Min.Y, w, h))
-
-	r.defs = r.canvas.CreateElement("defs")
-
-	r.iconCache = make(map[string]*etree.Document)
-
-	return r
+	return NewCanvasRenderer(state, opts, NewSVGCanvas)
 }
 
-func (r *Renderer) getIcon(iconFile string) *etree.Document {
-	icon, ok := r.iconCache[iconFile]
-	if ok {
-		return icon
-	}
-	defer func() { r.iconCache[iconFile] = icon }()
-
-	buf, err := iconsFS.ReadFile("icons/" + iconFile + ".svg")
-	if err != nil {
-		log.Printf("error reading icon %s: %s", iconFile, err.Error())
-		return nil
+// NewCanvasRenderer creates renderer which draws on canvas created by
+// newCanvas, i.e. to produce other output formats
+func NewCanvasRenderer(state *sgm.GameState, opts RenderOptions, newCanvas CanvasFactory) *Renderer {
+	r := &Renderer{state: state, opts: opts}
+	r.styles = newStyles(r.loadTheme())
+	if opts.FogOfWar {
+		r.visibility = state.ComputeVisibility(opts.PointOfView)
 	}
+	r.war = r.findWar()
+	r.computeBounds()
+	r.buildStarIndex()
 
-	icon = etree.NewDocument()
-	err = icon.ReadFromBytes(buf)
-	if err != nil {
-		log.Printf("error parsing icon %s: %s", iconFile, err.Error())
-		return nil
+	width, height := r.canvasSize()
+	r.canvas = newCanvas(r.bounds, width, height)
+	if iconCanvas, ok := r.canvas.(IconCanvas); ok && opts.IconDir != "" {
+		iconCanvas.SetIconDir(opts.IconDir)
 	}
-
-	// Remove some inkscape junk
-	svg := icon.Root()
-	svg.RemoveChild(svg.FindElement("defs"))
-	svg.RemoveChild(svg.FindElement("metadata"))
-	return icon
-}
-
-func (r *Renderer) computeBounds() {
-	for _, star := range r.state.Stars {
-		r.bounds.Add(star.Point())
+	if layerCanvas, ok := r.canvas.(LayerControlCanvas); ok && opts.Interactive {
+		layerCanvas.EnableLayerControls()
 	}
-
-	r.innerBounds = r.bounds
-	r.bounds.Expand(canvasPadding)
+	return r
 }
 
 func (r *Renderer) Render() {
-	r.createRect(r.canvas, backgroundStyle, r.bounds)
+	r.createLayers()
+	r.useLayer(layerBackground)
+	r.layer.CreateRect(r.styles.backgroundStyle, r.bounds)
 
+	r.useLayer(layerCountries)
 	countries := r.renderCountries()
+	r.useLayer(layerOverlays)
+	r.renderOverlays()
+	r.renderDistanceRings()
+	r.useLayer(layerWarFronts)
+	r.renderWarFronts()
+	r.useLayer(layerGrid)
 	r.renderGrid()
+
+	r.useLayer(layerHyperlanes)
 	r.renderHyperlanes()
+	r.renderBypassLinks()
+	r.renderRoute()
 
+	r.useLayer(layerStars)
 	significantStars := r.renderStars()
-
-	r.renderCountryNames(countries)
 	for _, ctx := range significantStars {
 		r.renderStarbase(ctx)
 		r.renderStarFeatures(ctx)
 	}
+
+	r.useLayer(layerBattles)
+	r.renderBattleHistory()
+	r.useLayer(layerChokepoints)
+	r.renderChokepoints()
+
+	r.useLayer(layerCountryNames)
+	r.renderCountryNames(countries)
+	r.useLayer(layerStarNames)
 	for _, ctx := range significantStars {
 		r.renderStarName(ctx)
 	}
 
+	r.useLayer(layerLegend)
+	r.renderOverlayLegends()
+	r.renderWarSummary()
+
+	legend := Legend{
+		Title:    mapTitle,
+		Subtitle: r.state.Name,
+		Date:     fmt.Sprintf("Year %d", r.state.Date.Year()),
+		Footer:   footerText,
+
+		Background: r.styles.backgroundStyle.Get("fill"),
+	}
+	if legendCanvas, ok := r.canvas.(LegendCanvas); ok {
+		legendCanvas.SetLegend(legend)
+	} else {
+		r.renderLegend(legend)
+	}
+}
+
+func (r *Renderer) setDetailLevel(level DetailLevel) {
+	if detailCanvas, ok := r.canvas.(DetailCanvas); ok {
+		detailCanvas.SetDetailLevel(level)
+	}
+}
+
+// renderLegend draws the title block in the top left corner of the map and
+// the footer in the bottom right one
+func (r *Renderer) renderLegend(legend Legend) {
 	titlePoint := r.innerBounds.Min
-	r.createText(r.canvas, countryTextStyle, titlePoint, mapTitle)
-	titlePoint.Y += countryFontSize
-	r.createText(r.canvas, countryLegendStyle, titlePoint, r.state.Name)
-	titlePoint.Y += 0.6 * countryFontSize
-	r.createText(r.canvas, countryLegendStyle, titlePoint,
-		fmt.Sprintf("Year %d", r.state.Date.Year()))
-
-	footerStyle := starTextStyle.With(StyleOption{"text-anchor", "end"})
-	footerPoint := r.innerBounds.Max.Add(sgmmath.Point{0.0, -2 * fontSize})
-	r.createText(r.canvas, footerStyle, footerPoint, footerText)
+	r.layer.CreateText(r.styles.countryTextStyle, titlePoint, legend.Title)
+	titlePoint.Y += r.styles.countryFontSize
+	r.layer.CreateText(r.styles.countryLegendStyle, titlePoint, legend.Subtitle)
+	titlePoint.Y += 0.6 * r.styles.countryFontSize
+	r.layer.CreateText(r.styles.countryLegendStyle, titlePoint, legend.Date)
+
+	footerStyle := r.styles.starTextStyle.With(StyleOption{"text-anchor", "end"})
+	footerPoint := r.innerBounds.Max.Add(sgmmath.Point{X: 0.0, Y: -2 * r.styles.fontSize})
+	r.layer.CreateText(footerStyle, footerPoint, legend.Footer)
 }
 
 func (r *Renderer) renderGrid() {
@@ -167,80 +263,34 @@ func (r *Renderer) renderGrid() {
 	gridStepX, gridStepY := w/gridSplit, h/gridSplit
 
 	for x := r.bounds.Min.X + gridStepX; x < r.bounds.Max.X-gridStepX/2; x += gridStepX {
-		startPoint := sgmmath.Point{x, r.bounds.Min.Y - canvasPadding/2}
+		startPoint := sgmmath.Point{X: x, Y: r.bounds.Min.Y - canvasPadding/2}
 		path := NewPath().MoveToPoint(startPoint).VertLine(r.bounds.Max.Y + canvasPadding/2)
-		r.createPath(r.canvas, gridStyle, path)
+		r.layer.CreatePath(r.styles.gridStyle, path)
 	}
 	for y := r.bounds.Min.Y + gridStepY; y < r.bounds.Max.Y-gridStepY/2; y += gridStepY {
-		startPoint := sgmmath.Point{r.bounds.Min.X - canvasPadding/2, y}
+		startPoint := sgmmath.Point{X: r.bounds.Min.X - canvasPadding/2, Y: y}
 		path := NewPath().MoveToPoint(startPoint).HorLine(r.bounds.Max.X + canvasPadding/2)
-		r.createPath(r.canvas, gridStyle, path)
+		r.layer.CreatePath(r.styles.gridStyle, path)
 	}
 }
 
-func (r *Renderer) createPath(el *etree.Element, style Style, path Path) *etree.Element {
-	pathEl := el.CreateElement("path")
-	pathEl.CreateAttr("style", style.String())
-	pathEl.CreateAttr("d", path.String())
-	return pathEl
-}
-
-func (r *Renderer) createRect(el *etree.Element, style Style, rect sgmmath.BoundingRect) *etree.Element {
-	w, h := rect.Size()
-	rectEl := el.CreateElement("rect")
-	rectEl.CreateAttr("style", style.String())
-	rectEl.CreateAttr("x", fmt.Sprintf("%.4f", rect.Min.X))
-	rectEl.CreateAttr("y", fmt.Sprintf("%.4f", rect.Min.Y))
-	rectEl.CreateAttr("width", fmt.Sprintf("%.4f", w))
-	rectEl.CreateAttr("height", fmt.Sprintf("%.4f", h))
-	return rectEl
-}
-
-func (r *Renderer) createCircle(
-	el *etree.Element, style Style, center sgmmath.Point, radius float64,
-) *etree.Element {
-	circleEl := el.CreateElement("circle")
-	circleEl.CreateAttr("cx", fmt.Sprintf("%.4f", center.X))
-	circleEl.CreateAttr("cy", fmt.Sprintf("%.4f", center.Y))
-	circleEl.CreateAttr("r", fmt.Sprintf("%.4f", radius))
-	circleEl.CreateAttr("style", style.String())
-	return circleEl
-}
-
-func (r *Renderer) createText(
-	el *etree.Element, style Style, point sgmmath.Point, text string,
-) *etree.Element {
-	textEl := el.CreateElement("text")
-	textEl.CreateAttr("x", fmt.Sprintf("%.4f", point.X))
-	textEl.CreateAttr("y", fmt.Sprintf("%.4f", point.Y))
-	textEl.CreateAttr("style", style.String())
-	textEl.CreateText(text)
-	return textEl
-}
-
-func (r *Renderer) createIcon(el *etree.Element, p sgmmath.Point, iconName string, size float64) {
-	g := el.CreateElement("g")
-	g.CreateAttr("transform",
-		fmt.Sprintf("translate(%f, %f) scale(%f)", p.X, p.Y, size/float64(svgIconSize)))
-
-	icon := r.getIcon(iconName)
-	if icon == nil {
-		// TODO: render some placeholder
-		return
+func (r *Renderer) Write(outPath string) error {
+	if dirCanvas, ok := r.canvas.(DirCanvas); ok {
+		return dirCanvas.WriteDir(outPath)
 	}
 
-	g.AddChild(icon.Root().Copy())
-}
-
-func (r *Renderer) createTitle(el *etree.Element, title string) {
-	titleEl := el.CreateElement("title")
-	titleEl.CreateText(title)
-}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-func (r *Renderer) Write(outPath string) error {
-	return r.doc.WriteToFile(outPath)
+	_, err = r.canvas.WriteTo(f)
+	return err
 }
 
 func (r *Renderer) WriteToBytes() ([]byte, error) {
-	return r.doc.WriteToBytes()
+	var buf bytes.Buffer
+	_, err := r.canvas.WriteTo(&buf)
+	return buf.Bytes(), err
 }
@@ -0,0 +1,37 @@
+package sgmrender
+
+import (
+	"log"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+)
+
+func (r *Renderer) renderRoute() {
+	if len(r.opts.Route) == 0 {
+		return
+	}
+
+	var stars []*sgm.Star
+	for _, starId := range r.opts.Route {
+		star := r.state.Stars[starId]
+		if star == nil {
+			log.Printf("warn: star #%d of the route is not found", starId)
+			return
+		}
+		stars = append(stars, star)
+	}
+
+	path := NewPath().MoveToPoint(stars[0].Point())
+	for i, star := range stars[1:] {
+		// Jumps over bypasses are drawn as arcs like bypass links
+		if stars[i].HasHyperlane(star) {
+			path = path.LineToPoint(star.Point())
+		} else {
+			path = path.QuadTo(bypassArcControl(stars[i].Point(), star.Point()), star.Point())
+		}
+	}
+	r.layer.CreatePath(r.styles.routeStyle, path)
+
+	r.layer.CreateCircle(r.styles.routeEndStyle, stars[0].Point(), 2*starbaseHalfSize)
+	r.layer.CreateCircle(r.styles.routeEndStyle, stars[len(stars)-1].Point(), 2*starbaseHalfSize)
+}
@@ -0,0 +1,338 @@
+package sgmrender
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/beevik/etree"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+type sceneNodeKind int
+
+const (
+	sceneNodeGroup sceneNodeKind = iota
+	sceneNodeShape
+	sceneNodeText
+)
+
+// sceneNode is an element of the scene graph kept in memory by backends
+// which cannot write elements as they are created, i.e. raster images
+type sceneNode struct {
+	kind      sceneNodeKind
+	style     Style
+	title     string
+	transform affine
+
+	children []*sceneNode
+
+	outline outline
+	point   sgmmath.Point
+	text    string
+
+	detail DetailLevel
+	// Box containing the node in its own coordinates
+	box       sgmmath.BoundingRect
+	hasBounds bool
+}
+
+func (n *sceneNode) SetTitle(title string) {
+	n.title = title
+}
+
+func (n *sceneNode) SetId(id string) {
+}
+
+func (n *sceneNode) SetData(name, value string) {
+	// Static images are not interactive
+}
+
+func (n *sceneNode) Animate(animation Animation) {
+	// Static images do not support animations
+}
+
+func (n *sceneNode) addChild(child *sceneNode) *sceneNode {
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *sceneNode) computeBounds(fontSize float64) {
+	if n.hasBounds {
+		// Icons are shared by multiple groups
+		return
+	}
+	fontSize = parseStyleLength(n.style.Get("font-size"), fontSize)
+
+	box := sgmmath.NewBoundingRect()
+	switch n.kind {
+	case sceneNodeGroup:
+		for _, child := range n.children {
+			child.computeBounds(fontSize)
+			if child.box.IsZero() {
+				continue
+			}
+			for _, p := range boxCorners(child.box) {
+				box.Add(child.transform.apply(p))
+			}
+		}
+	case sceneNodeShape:
+		for _, op := range n.outline {
+			switch op.cmd {
+			case 'M', 'L':
+				box.Add(op.pts[0])
+			case 'C':
+				for _, p := range op.pts {
+					box.Add(p)
+				}
+			}
+		}
+	case sceneNodeText:
+		// Anchor and font are not known here, so assume that glyphs are
+		// never wider than font size
+		width := fontSize * float64(len([]rune(n.text)))
+		box.Add(n.point.Add(sgmmath.Point{X: -width, Y: -fontSize}))
+		box.Add(n.point.Add(sgmmath.Point{X: width, Y: fontSize / 2}))
+	}
+	n.box, n.hasBounds = box, true
+}
+
+func boxCorners(box sgmmath.BoundingRect) [4]sgmmath.Point {
+	return [4]sgmmath.Point{
+		box.Min, {X: box.Max.X, Y: box.Min.Y},
+		box.Max, {X: box.Min.X, Y: box.Max.Y},
+	}
+}
+
+type sceneGroup struct {
+	*sceneNode
+	scene *Scene
+}
+
+// add appends a new element to the group tagging it with the current
+// detail level of the scene
+func (g sceneGroup) add(node *sceneNode) *sceneNode {
+	node.detail = g.scene.detail
+	return g.addChild(node)
+}
+
+func (g sceneGroup) newGroup(style Style, transform affine) sceneGroup {
+	node := g.add(&sceneNode{kind: sceneNodeGroup, style: style, transform: transform})
+	return sceneGroup{sceneNode: node, scene: g.scene}
+}
+
+func (g sceneGroup) CreateGroup(style Style) Group {
+	return g.newGroup(style, identityAffine)
+}
+
+func (g sceneGroup) CreateTranslatedGroup(point sgmmath.Point) Group {
+	return g.newGroup(Style{}, translateAffine(point.X, point.Y))
+}
+
+func (g sceneGroup) createShape(style Style, o outline) Element {
+	return g.add(&sceneNode{
+		kind:      sceneNodeShape,
+		style:     style,
+		transform: identityAffine,
+		outline:   o,
+	})
+}
+
+func (g sceneGroup) CreatePath(style Style, path Path) Element {
+	return g.createShape(style, outlineFromPath(path))
+}
+
+func (g sceneGroup) CreateRect(style Style, rect sgmmath.BoundingRect) Element {
+	return g.createShape(style, rectOutline(rect))
+}
+
+func (g sceneGroup) CreateCircle(style Style, center sgmmath.Point, radius float64) Element {
+	return g.createShape(style, circleOutline(center, radius))
+}
+
+func (g sceneGroup) CreateText(style Style, point sgmmath.Point, text string) Element {
+	return g.add(&sceneNode{
+		kind:      sceneNodeText,
+		style:     style,
+		transform: identityAffine,
+		point:     point,
+		text:      text,
+	})
+}
+
+func (g sceneGroup) CreateIcon(point sgmmath.Point, name string, size float64) Element {
+	scale := size / float64(svgIconSize)
+	group := g.newGroup(Style{},
+		translateAffine(point.X, point.Y).mul(scaleAffine(scale, scale)))
+
+	group.addChild(g.scene.getIcon(name))
+	return group
+}
+
+type scenePattern struct {
+	size float64
+	root *sceneNode
+}
+
+// Scene is a canvas which keeps all elements in memory, so raster backends
+// can draw them on images of arbitrary size
+type Scene struct {
+	sceneGroup
+
+	Bounds        sgmmath.BoundingRect
+	Width, Height float64
+
+	patterns  map[string]*scenePattern
+	gradients map[string][]string
+	iconDir   string
+	iconCache map[string]*sceneNode
+
+	detail     DetailLevel
+	boundsOnce sync.Once
+}
+
+func NewScene(bounds sgmmath.BoundingRect, width, height float64) *Scene {
+	s := &Scene{
+		Bounds:    bounds,
+		Width:     width,
+		Height:    height,
+		patterns:  make(map[string]*scenePattern),
+		gradients: make(map[string][]string),
+		iconCache: make(map[string]*sceneNode),
+	}
+	s.sceneGroup = sceneGroup{
+		sceneNode: &sceneNode{kind: sceneNodeGroup, transform: identityAffine},
+		scene:     s,
+	}
+	return s
+}
+
+func (s *Scene) CreatePattern(id string, size float64) Group {
+	pattern := &scenePattern{
+		size: size,
+		root: &sceneNode{kind: sceneNodeGroup, transform: identityAffine},
+	}
+	s.patterns[id] = pattern
+	return sceneGroup{sceneNode: pattern.root, scene: s}
+}
+
+// CreateLayer creates plain group as raster images have no layers
+func (s *Scene) CreateLayer(id, label string) Group {
+	return s.CreateGroup(Style{})
+}
+
+func (s *Scene) SetDetailLevel(level DetailLevel) {
+	s.detail = level
+}
+
+// computeBounds finds boxes of all nodes when the scene is complete, so
+// rasterizers which may run in parallel can skip nodes outside of images
+func (s *Scene) computeBounds() {
+	s.boundsOnce.Do(func() {
+		s.sceneNode.computeBounds(defaultRasterStyle.fontSize)
+		for _, pattern := range s.patterns {
+			pattern.root.computeBounds(defaultRasterStyle.fontSize)
+		}
+	})
+}
+
+func (s *Scene) CreateLinearGradient(id string, colors []string) {
+	s.gradients[id] = colors
+}
+
+func (s *Scene) HasDefinition(id string) bool {
+	_, isPattern := s.patterns[id]
+	_, isGradient := s.gradients[id]
+	return isPattern || isGradient
+}
+
+func (s *Scene) SetIconDir(dir string) {
+	s.iconDir = dir
+	s.iconCache = make(map[string]*sceneNode)
+}
+
+func (s *Scene) getIcon(iconFile string) *sceneNode {
+	icon, ok := s.iconCache[iconFile]
+	if !ok {
+		icon = newIconSceneNode(loadIcon(s.iconDir, iconFile).Root())
+		s.iconCache[iconFile] = icon
+	}
+	return icon
+}
+
+// newIconSceneNode converts icon svg into scene graph scaled to
+// svgIconSize, shapes which are not used by icons are ignored
+func newIconSceneNode(svg *etree.Element) *sceneNode {
+	root := newIconElementNode(svg)
+
+	width := parseAttrFloat(svg, "width", svgIconSize)
+	viewBox := parseNumbers(svg.SelectAttrValue("viewBox", ""))
+	if len(viewBox) == 4 && viewBox[2] > 0 {
+		scale := width / viewBox[2]
+		root.transform = scaleAffine(scale, scale).mul(translateAffine(-viewBox[0], -viewBox[1]))
+	}
+	return root
+}
+
+func newIconElementNode(el *etree.Element) *sceneNode {
+	node := &sceneNode{
+		style:     parseStyleAttr(el.SelectAttrValue("style", "")),
+		transform: parseTransform(el.SelectAttrValue("transform", "")),
+	}
+
+	switch el.Tag {
+	case "svg", "g":
+		node.kind = sceneNodeGroup
+		for _, child := range el.ChildElements() {
+			if childNode := newIconElementNode(child); childNode != nil {
+				node.addChild(childNode)
+			}
+		}
+	case "path":
+		node.kind = sceneNodeShape
+		node.outline = parsePathData(el.SelectAttrValue("d", ""))
+	case "circle":
+		node.kind = sceneNodeShape
+		r := parseAttrFloat(el, "r", 0)
+		node.outline = ellipseOutline(parseAttrPoint(el, "cx", "cy"), r, r)
+	case "ellipse":
+		node.kind = sceneNodeShape
+		node.outline = ellipseOutline(parseAttrPoint(el, "cx", "cy"),
+			parseAttrFloat(el, "rx", 0), parseAttrFloat(el, "ry", 0))
+	case "rect":
+		node.kind = sceneNodeShape
+		min := parseAttrPoint(el, "x", "y")
+		node.outline = rectOutline(sgmmath.BoundingRect{
+			Min: min,
+			Max: min.Add(parseAttrPoint(el, "width", "height")),
+		})
+	default:
+		return nil
+	}
+	return node
+}
+
+func parseAttrFloat(el *etree.Element, attr string, def float64) float64 {
+	value, err := strconv.ParseFloat(el.SelectAttrValue(attr, ""), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func parseAttrPoint(el *etree.Element, attrX, attrY string) sgmmath.Point {
+	return sgmmath.Point{X: parseAttrFloat(el, attrX, 0), Y: parseAttrFloat(el, attrY, 0)}
+}
+
+func parseStyleAttr(s string) Style {
+	style := newStyle()
+	for _, decl := range strings.Split(s, ";") {
+		colon := strings.IndexByte(decl, ':')
+		if colon < 0 {
+			continue
+		}
+		style.m[strings.TrimSpace(decl[:colon])] = strings.TrimSpace(decl[colon+1:])
+	}
+	return style
+}
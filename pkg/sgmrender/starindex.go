@@ -35,12 +35,12 @@ type StarGeoIndex map[sgm.StarId]StarAdjacencyList
 func (r *Renderer) buildStarIndex() {
 	type starQuadrantSet map[uint8]struct{}
 
-	w, h := r.bounds.Size()
+	w, h := r.galaxyBounds.Size()
 	starIndex := make([][]sgm.StarId, 256)
 	starQuadrants := make(map[sgm.StarId]starQuadrantSet)
 	getStarQuadrant := func(point sgmmath.Point) uint8 {
-		return (uint8(maxQuadrantIndex*(point.X-r.bounds.Min.X)/w)<<4 |
-			uint8(maxQuadrantIndex*(point.Y-r.bounds.Min.Y)/h))
+		return (uint8(maxQuadrantIndex*(point.X-r.galaxyBounds.Min.X)/w)<<4 |
+			uint8(maxQuadrantIndex*(point.Y-r.galaxyBounds.Min.Y)/h))
 	}
 
 	hqw, hqh := w/quadrantCount/2, h/quadrantCount/2
@@ -49,11 +49,11 @@ func (r *Renderer) buildStarIndex() {
 
 		// If star is offset, try it in different quadrants
 		for _, d := range []sgmmath.Point{
-			{0.0, 0.0},
-			{-hqw, hqh},
-			{hqw, hqh},
-			{hqw, -hqh},
-			{-hqw, -hqh},
+			{X: 0.0, Y: 0.0},
+			{X: -hqw, Y: hqh},
+			{X: hqw, Y: hqh},
+			{X: hqw, Y: -hqh},
+			{X: -hqw, Y: -hqh},
 		} {
 			quadrantIdx := getStarQuadrant(star.Point().Add(d))
 			if _, touchedQuadrant := quadrants[quadrantIdx]; touchedQuadrant {
@@ -103,7 +103,6 @@ func (r *Renderer) buildStarIndex() {
 	}
 }
 
-//
 // Returns quadrants: I - 0, II - 1, III - -2, IV - -1
 func (r *Renderer) pickTextQuadrant(starId sgm.StarId) int {
 	quadrantWeight := make([]float64, 4)
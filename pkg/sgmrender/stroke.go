@@ -0,0 +1,249 @@
+package sgmrender
+
+import (
+	"math"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// rasterStroker converts flattened lines into polygons covering their
+// strokes. Polygons may overlap, so all of them are oriented in the same
+// direction to be filled with nonzero rule.
+type rasterStroker struct {
+	halfWidth  float64
+	lineCap    string
+	lineJoin   string
+	miterLimit float64
+
+	dashes     []float64
+	dashOffset float64
+
+	polygons []polyline
+}
+
+func (st *rasterStroker) stroke(lines []polyline) []polyline {
+	st.polygons = nil
+	for _, line := range lines {
+		points := dedupPoints(line.points)
+		closed := line.closed && len(points) > 2
+		if closed && points[0] == points[len(points)-1] {
+			points = points[:len(points)-1]
+		}
+
+		if !st.hasDashes() {
+			st.strokeLine(points, closed)
+			continue
+		}
+		if closed {
+			points = append(points, points[0])
+		}
+		for _, dash := range st.dashLine(points) {
+			st.strokeLine(dash, false)
+		}
+	}
+	return st.polygons
+}
+
+func dedupPoints(points []sgmmath.Point) []sgmmath.Point {
+	result := make([]sgmmath.Point, 0, len(points))
+	for _, p := range points {
+		if len(result) == 0 || result[len(result)-1].Distance(p) > 1e-6 {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (st *rasterStroker) hasDashes() bool {
+	total := 0.0
+	for _, dash := range st.dashes {
+		if dash < 0 {
+			return false
+		}
+		total += dash
+	}
+	return total > 0
+}
+
+// dashLine splits line into dashes, odd number of dash lengths is
+// repeated twice like in SVG
+func (st *rasterStroker) dashLine(points []sgmmath.Point) [][]sgmmath.Point {
+	dashes := st.dashes
+	if len(dashes)%2 == 1 {
+		dashes = append(append([]float64{}, dashes...), dashes...)
+	}
+	total := 0.0
+	for _, dash := range dashes {
+		total += dash
+	}
+
+	// Find dash where the line starts taking offset into account
+	index, remaining := 0, dashes[0]
+	offset := positiveMod(st.dashOffset, total)
+	for offset > 0 {
+		if offset < remaining {
+			remaining -= offset
+			break
+		}
+		offset -= remaining
+		index = (index + 1) % len(dashes)
+		remaining = dashes[index]
+	}
+
+	var result [][]sgmmath.Point
+	var cur []sgmmath.Point
+	if index%2 == 0 {
+		cur = []sgmmath.Point{points[0]}
+	}
+	for i := 1; i < len(points); i++ {
+		p0, p1 := points[i-1], points[i]
+		length := p0.Distance(p1)
+		pos := 0.0
+		for length-pos > remaining {
+			pos += remaining
+			p := p0.Add(p1.Sub(p0).Mul(pos / length))
+			if index%2 == 0 {
+				result = append(result, append(cur, p))
+				cur = nil
+			} else {
+				cur = []sgmmath.Point{p}
+			}
+			index = (index + 1) % len(dashes)
+			remaining = dashes[index]
+		}
+		remaining -= length - pos
+		if cur != nil {
+			cur = append(cur, p1)
+		}
+	}
+	if len(cur) > 1 {
+		result = append(result, cur)
+	}
+	return result
+}
+
+func (st *rasterStroker) strokeLine(points []sgmmath.Point, closed bool) {
+	if len(points) == 0 {
+		return
+	}
+	if len(points) == 1 {
+		// Zero-length subpaths only have caps
+		switch st.lineCap {
+		case "round":
+			st.addCircle(points[0])
+		case "square":
+			st.addSquare(points[0], sgmmath.Point{X: 1})
+		}
+		return
+	}
+
+	n := len(points)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		p0, p1 := points[i], points[(i+1)%n]
+		normal := st.normal(p0, p1)
+		st.addPolygon(p0.Add(normal), p1.Add(normal), p1.Sub(normal), p0.Sub(normal))
+	}
+
+	for i := 0; i < n; i++ {
+		if !closed && (i == 0 || i == n-1) {
+			continue
+		}
+		st.addJoin(points[(i+n-1)%n], points[i], points[(i+1)%n])
+	}
+
+	if !closed {
+		st.addCap(points[0], points[1])
+		st.addCap(points[n-1], points[n-2])
+	}
+}
+
+// normal returns vector of half width length perpendicular to the segment
+func (st *rasterStroker) normal(p0, p1 sgmmath.Point) sgmmath.Point {
+	d := p1.Sub(p0)
+	length := math.Hypot(d.X, d.Y)
+	return sgmmath.Point{X: -d.Y / length * st.halfWidth, Y: d.X / length * st.halfWidth}
+}
+
+func (st *rasterStroker) addJoin(prev, p, next sgmmath.Point) {
+	if st.lineJoin == "round" {
+		st.addCircle(p)
+		return
+	}
+
+	n0, n1 := st.normal(prev, p), st.normal(p, next)
+	d0, d1 := p.Sub(prev), next.Sub(p)
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	if cross > 0 {
+		// Outer side of the turn is on the other side of normals
+		n0, n1 = n0.Mul(-1), n1.Mul(-1)
+	}
+	a, b := p.Add(n0), p.Add(n1)
+
+	if st.lineJoin != "bevel" {
+		// Miter length relative to stroke width is 1 / sin(theta / 2)
+		// where theta is angle between segments
+		dot := (n0.X*n1.X + n0.Y*n1.Y) / (st.halfWidth * st.halfWidth)
+		cosHalf := math.Sqrt(math.Max(0, (1+dot)/2))
+		if cosHalf > 1e-6 && 1/cosHalf <= st.miterLimit {
+			mid := n0.Add(n1)
+			midLength := math.Hypot(mid.X, mid.Y)
+			if midLength > 1e-9 {
+				tip := p.Add(mid.Mul(st.halfWidth / cosHalf / midLength))
+				st.addPolygon(p, a, tip, b)
+				return
+			}
+		}
+	}
+	st.addPolygon(p, a, b)
+}
+
+func (st *rasterStroker) addCap(p, neighbour sgmmath.Point) {
+	switch st.lineCap {
+	case "round":
+		st.addCircle(p)
+	case "square":
+		st.addSquare(p, p.Sub(neighbour))
+	}
+}
+
+// addSquare adds square cap centered in p and oriented along dir
+func (st *rasterStroker) addSquare(p, dir sgmmath.Point) {
+	length := math.Hypot(dir.X, dir.Y)
+	d := dir.Mul(st.halfWidth / length)
+	n := sgmmath.Point{X: -d.Y, Y: d.X}
+	st.addPolygon(p.Add(n).Sub(d), p.Add(n).Add(d), p.Sub(n).Add(d), p.Sub(n).Sub(d))
+}
+
+func (st *rasterStroker) addCircle(center sgmmath.Point) {
+	steps := int(math.Ceil(2 * math.Pi * st.halfWidth / 2))
+	if steps < 8 {
+		steps = 8
+	} else if steps > maxCurveSegments {
+		steps = maxCurveSegments
+	}
+
+	points := make([]sgmmath.Point, steps)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(steps))
+		points[i] = center.Add(sgmmath.Point{X: cos * st.halfWidth, Y: sin * st.halfWidth})
+	}
+	st.addPolygon(points...)
+}
+
+func (st *rasterStroker) addPolygon(points ...sgmmath.Point) {
+	area := 0.0
+	for i := range points {
+		p0, p1 := points[i], points[(i+1)%len(points)]
+		area += p0.X*p1.Y - p1.X*p0.Y
+	}
+	if area < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	st.polygons = append(st.polygons, polyline{points: points, closed: true})
+}
@@ -3,171 +3,462 @@ package sgmrender
 import (
 	"bytes"
 	"fmt"
+	"log"
+	"sort"
 
 	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
 )
 
-const (
-	countryFontSize = 8.0
-
-	colorBackground = "#2e4053"
-
-	colorPrimaryStroke = "#212f3c"
-	colorPrimaryFill   = "#f2f2f2"
-
-	colorFleetStroke = "#2e4053"
-	colorFleetFill   = "#aeb6bf"
-
-	colorStarStroke = "#ac9d93"
-	colorStarFill   = "#e9c6af"
-
-	colorStarbaseStroke = "#2e86c1"
-	colorStarbaseFill   = "#aed6f1"
-
-	colorHostileStroke = "#a93226"
-	colorHostileFill   = "#f2d7d5"
-
-	colorFriendlyStroke = "#217844"
-	colorFriendlyFill   = "#afe9c6"
+// styles are built from the theme when renderer is created
+type styles struct {
+	fontSize, countryFontSize float64
+	iconSizeSm, iconStepSm    float64
+	iconSizeMd, iconStepMd    float64
+
+	countryBorderColor, countryFillColor string
+
+	backgroundStyle        Style
+	gridStyle              Style
+	hyperlaneStyle         Style
+	defaultStarStyle       Style
+	unknownStarStyle       Style
+	outpostStyle           Style
+	outpostLostStyle       Style
+	baseStarbaseStyle      Style
+	starbaseLostStyle      Style
+	fleetIdentStyle        Style
+	basePlanetStyle        Style
+	planetRingStyle        Style
+	starTextStyle          Style
+	battleTextStyle        Style
+	countryTextStyle       Style
+	countryLegendStyle     Style
+	playerTextStyle        Style
+	baseCountryStyle       Style
+	humanCountryStyle      Style
+	occupationCountryStyle Style
+	occupationPatternStyle Style
+	monsterRingStyle       Style
+	guardedStarStyle       Style
+	crisisCountryStyle     Style
+	crisisPatternStyle     Style
+	bypassLinkStyle        Style
+	bypassTextStyle        Style
+	routeStyle             Style
+	routeEndStyle          Style
+	chokepointStyle        Style
+	battleAttackerWonStyle Style
+	battleDefenderWonStyle Style
+	warFrontCasingStyle    Style
+	warFrontStyle          Style
+	warPocketStyle         Style
+	warOccupiedStyle       Style
+	warPanelStyle          Style
+	warSideTextStyle       Style
+	warBarStyle            Style
+	overlayCellStyle       Style
+	overlayLegendStyle     Style
+	distanceRingStyle      Style
+	fleetTextStyle         Style
+
+	starbaseStrokes     map[string]float64
+	fleetStyles         map[sgm.WarRole]Style
+	monsterDangerColors map[sgm.DangerLevel]string
+	crisisStrokeColors  map[sgm.CrisisType]string
+	crisisFillColors    map[sgm.CrisisType]string
+	bypassLinkColors    map[string]string
+	warSideStrokeColors map[sgm.WarSide]string
+	warSideFillColors   map[sgm.WarSide]string
+}
 
-	colorPlanetStroke = "#27ae60"
-	colorPlanetFill   = "#abebc6"
-)
+func newStyles(theme *Theme) *styles {
+	c, sz := theme.Colors, theme.Sizes
+	s := &styles{
+		fontSize:        sz.LineHeight,
+		countryFontSize: sz.CountryFont,
+		iconSizeSm:      sz.IconSmall,
+		iconStepSm:      sz.IconSmallStep,
+		iconSizeMd:      sz.IconMedium,
+		iconStepMd:      sz.IconMediumStep,
+
+		countryBorderColor: c.CountryBorder,
+		countryFillColor:   c.CountryFill,
+	}
 
-var (
-	backgroundStyle = NewStyle(
+	s.backgroundStyle = NewStyle(
 		StyleOption{"stroke", "none"},
-		StyleOption{"fill", colorBackground},
+		StyleOption{"fill", c.Background},
 	)
 
-	gridStyle = NewStyle(
+	s.gridStyle = NewStyle(
 		StyleOption{"stroke-width", "0.33pt"},
-		StyleOption{"stroke", "#e5e8e8"},
+		StyleOption{"stroke", c.Grid},
 		StyleOption{"stroke-opacity", "0.2"},
 	)
 
-	hyperlaneStyle = NewStyle(
+	s.hyperlaneStyle = NewStyle(
 		StyleOption{"stroke-width", "0.33pt"},
-		StyleOption{"stroke", colorPrimaryStroke},
+		StyleOption{"stroke", c.PrimaryStroke},
 	)
 
-	defaultStarStyle = NewStyle(
+	s.defaultStarStyle = NewStyle(
 		StyleOption{"stroke-width", "0.33pt"},
-		StyleOption{"stroke", colorStarStroke},
-		StyleOption{"fill", colorStarFill},
+		StyleOption{"stroke", c.StarStroke},
+		StyleOption{"fill", c.StarFill},
 	)
 
-	outpostStyle = NewStyle(
+	s.unknownStarStyle = s.defaultStarStyle.With(
+		StyleOption{"stroke", c.FleetStroke},
+		StyleOption{"fill", c.FleetFill},
+		StyleOption{"fill-opacity", "0.5"},
+	)
+
+	s.outpostStyle = NewStyle(
 		StyleOption{"stroke-width", "0.2pt"},
-		StyleOption{"stroke", colorFleetStroke},
-		StyleOption{"fill", colorFleetFill},
+		StyleOption{"stroke", c.FleetStroke},
+		StyleOption{"fill", c.FleetFill},
 	)
 
-	outpostLostStyle = outpostStyle.With(
-		StyleOption{"stroke", colorHostileStroke},
-		StyleOption{"fill", colorHostileFill},
+	s.outpostLostStyle = s.outpostStyle.With(
+		StyleOption{"stroke", c.HostileStroke},
+		StyleOption{"fill", c.HostileFill},
 	)
 
-	baseStarbaseStyle = NewStyle(
+	s.baseStarbaseStyle = NewStyle(
 		StyleOption{"stroke-width", "0.33pt"},
-		StyleOption{"stroke", colorStarbaseStroke},
-		StyleOption{"fill", colorStarbaseFill},
+		StyleOption{"stroke", c.StarbaseStroke},
+		StyleOption{"fill", c.StarbaseFill},
 	)
 
-	starbaseLostStyle = baseStarbaseStyle.With(
-		StyleOption{"stroke", colorHostileStroke},
-		StyleOption{"fill", colorHostileFill},
+	s.starbaseLostStyle = s.baseStarbaseStyle.With(
+		StyleOption{"stroke", c.HostileStroke},
+		StyleOption{"fill", c.HostileFill},
 	)
 
-	starbaseStrokes = map[string]float64{
-		sgm.StarbaseStarport: 0.5,
-		sgm.StarbaseStarhold: 0.75,
-		sgm.StarbaseFortress: 1.0,
-		sgm.StarbaseCitadel:  1.0,
+	s.starbaseStrokes = make(map[string]float64)
+	for level, stroke := range sz.StarbaseStrokes {
+		s.starbaseStrokes["starbase_level_"+level] = stroke
 	}
 
-	fleetStyles = map[sgm.WarRole]Style{
+	s.fleetStyles = map[sgm.WarRole]Style{
 		sgm.WarRoleStarNeutral: NewStyle(
-			StyleOption{"stroke", colorFleetStroke},
-			StyleOption{"fill", colorFleetFill},
+			StyleOption{"stroke", c.FleetStroke},
+			StyleOption{"fill", c.FleetFill},
 		),
 		sgm.WarRoleStarAttacker: NewStyle(
-			StyleOption{"stroke", colorHostileStroke},
-			StyleOption{"fill", colorHostileFill},
+			StyleOption{"stroke", c.HostileStroke},
+			StyleOption{"fill", c.HostileFill},
 		),
 		sgm.WarRoleStarDefender: NewStyle(
-			StyleOption{"stroke", colorFriendlyStroke},
-			StyleOption{"fill", colorFriendlyFill},
+			StyleOption{"stroke", c.FriendlyStroke},
+			StyleOption{"fill", c.FriendlyFill},
 		),
 	}
 
-	fleetIdentStyle = NewStyle(
+	s.fleetIdentStyle = NewStyle(
 		StyleOption{"stroke-width", "0.2pt"},
 		StyleOption{"fill-opacity", "0.8"},
 	)
 
-	basePlanetStyle = NewStyle(
+	s.basePlanetStyle = NewStyle(
 		StyleOption{"stroke-width", "0.4pt"},
-		StyleOption{"stroke", colorPlanetStroke},
-		StyleOption{"fill", colorPlanetFill},
+		StyleOption{"stroke", c.PlanetStroke},
+		StyleOption{"fill", c.PlanetFill},
 	)
 
-	planetRingStyle = NewStyle(
+	s.planetRingStyle = NewStyle(
 		StyleOption{"stroke-width", "0.4pt"},
-		StyleOption{"stroke", colorFleetStroke},
+		StyleOption{"stroke", c.FleetStroke},
 		StyleOption{"fill", "none"},
 	)
 
-	starTextStyle = NewStyle(
+	s.starTextStyle = NewStyle(
 		StyleOption{"font-family", "sans-serif"},
-		StyleOption{"font-size", "3.2pt"},
+		StyleOption{"font-size", fmt.Sprintf("%gpt", sz.Font)},
 		StyleOption{"stroke-width", "0.08pt"},
-		StyleOption{"stroke", colorPrimaryStroke},
-		StyleOption{"fill", colorPrimaryFill},
+		StyleOption{"stroke", c.PrimaryStroke},
+		StyleOption{"fill", c.PrimaryFill},
 	)
 
-	battleTextStyle = starTextStyle.With(
-		StyleOption{"font-size", "2.4pt"},
+	s.battleTextStyle = s.starTextStyle.With(
+		StyleOption{"font-size", fmt.Sprintf("%gpt", sz.SmallFont)},
 		StyleOption{"stroke-width", "0.08pt"},
 	)
 
-	countryTextStyle = NewStyle(
+	s.countryTextStyle = NewStyle(
 		StyleOption{"font-family", "sans-serif"},
 		StyleOption{"stroke-width", "0.12pt"},
-		StyleOption{"font-size", fmt.Sprintf("%fpt", countryFontSize)},
+		StyleOption{"font-size", fmt.Sprintf("%gpt", sz.CountryFont)},
 		StyleOption{"font-variant", "petite-caps"},
-		StyleOption{"stroke", colorPrimaryStroke},
-		StyleOption{"fill", colorPrimaryFill},
+		StyleOption{"stroke", c.PrimaryStroke},
+		StyleOption{"fill", c.PrimaryFill},
+	)
+
+	s.countryLegendStyle = s.countryTextStyle.With(
+		StyleOption{"font-size", fmt.Sprintf("%gpt", sz.CountryFont/2)},
 	)
 
-	countryLegendStyle = countryTextStyle.With(
-		StyleOption{"font-size", fmt.Sprintf("%fpt", countryFontSize/2)},
+	s.playerTextStyle = s.countryLegendStyle.With(
+		StyleOption{"font-style", "italic"},
+		StyleOption{"font-variant", "normal"},
 	)
 
-	baseCountryStyle = NewStyle(
+	s.baseCountryStyle = NewStyle(
 		StyleOption{"stroke-width", "2pt"},
 		StyleOption{"stroke-linejoin", "miter"},
 		StyleOption{"fill-opacity", "0.6"},
 		StyleOption{"fill-rule", "evenodd"},
 	)
 
-	occupationCountryStyle = NewStyle(
+	s.humanCountryStyle = NewStyle(
+		StyleOption{"stroke-width", "0.6pt"},
+		StyleOption{"stroke", c.PrimaryFill},
+		StyleOption{"stroke-dasharray", "2.0,1.0"},
+		StyleOption{"stroke-linejoin", "miter"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.occupationCountryStyle = NewStyle(
 		StyleOption{"stroke-dasharray", "1.0,1.0"},
 	)
 
-	occupationPatternStyle = NewStyle(
+	s.occupationPatternStyle = NewStyle(
 		StyleOption{"stroke-width", "1pt"},
 		StyleOption{"stroke-opacity", "0.8"},
 	)
 
-	fleetTextStyle = NewStyle(
+	s.monsterDangerColors = make(map[sgm.DangerLevel]string)
+	for level := sgm.DangerLevelLow; level < sgm.DangerLevelMax; level++ {
+		s.monsterDangerColors[level] = c.MonsterDanger[level.String()]
+	}
+
+	s.monsterRingStyle = NewStyle(
+		StyleOption{"stroke-width", "0.4pt"},
+		StyleOption{"fill", c.HostileFill},
+		StyleOption{"fill-opacity", "0.6"},
+	)
+
+	s.guardedStarStyle = NewStyle(
+		StyleOption{"stroke-width", "0.4pt"},
+		StyleOption{"stroke", c.HostileStroke},
+		StyleOption{"stroke-dasharray", "0.8,0.8"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.crisisStrokeColors = make(map[sgm.CrisisType]string)
+	s.crisisFillColors = make(map[sgm.CrisisType]string)
+	for crisisType := sgm.CrisisPrethoryn; crisisType < sgm.CrisisTypeMax; crisisType++ {
+		colors := c.Crisis[crisisType.String()]
+		s.crisisStrokeColors[crisisType] = colors.Stroke
+		s.crisisFillColors[crisisType] = colors.Fill
+	}
+
+	s.crisisCountryStyle = s.baseCountryStyle.With(
+		StyleOption{"stroke-dasharray", "3.0,1.0"},
+		StyleOption{"fill-opacity", "0.8"},
+	)
+
+	s.crisisPatternStyle = NewStyle(
+		StyleOption{"stroke-width", "0.8pt"},
+		StyleOption{"stroke-opacity", "0.9"},
+	)
+
+	s.bypassLinkColors = c.BypassLinks
+
+	s.bypassLinkStyle = NewStyle(
+		StyleOption{"stroke-width", "0.5pt"},
+		StyleOption{"stroke-dasharray", "2.0,1.0"},
+		StyleOption{"stroke-opacity", "0.8"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.bypassTextStyle = s.battleTextStyle.With(
+		StyleOption{"font-weight", "bold"},
+		StyleOption{"text-anchor", "end"},
+	)
+
+	s.routeStyle = NewStyle(
+		StyleOption{"stroke-width", "1.6pt"},
+		StyleOption{"stroke", c.Route},
+		StyleOption{"stroke-opacity", "0.8"},
+		StyleOption{"stroke-linecap", "round"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.routeEndStyle = NewStyle(
+		StyleOption{"stroke-width", "0.8pt"},
+		StyleOption{"stroke", c.Route},
+		StyleOption{"fill", "none"},
+	)
+
+	s.chokepointStyle = NewStyle(
+		StyleOption{"stroke-width", "0.6pt"},
+		StyleOption{"stroke", c.HostileStroke},
+		StyleOption{"stroke-linejoin", "round"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.battleAttackerWonStyle = NewStyle(
+		StyleOption{"stroke-width", "0.4pt"},
+		StyleOption{"stroke", c.HostileStroke},
+		StyleOption{"fill", c.HostileFill},
+		StyleOption{"fill-opacity", "0.6"},
+	)
+
+	s.battleDefenderWonStyle = s.battleAttackerWonStyle.With(
+		StyleOption{"stroke", c.FriendlyStroke},
+		StyleOption{"fill", c.FriendlyFill},
+	)
+
+	s.warSideStrokeColors = make(map[sgm.WarSide]string)
+	s.warSideFillColors = make(map[sgm.WarSide]string)
+	for side := sgm.WarSideNone; side < sgm.WarSideMax; side++ {
+		colors := c.WarSides[side.String()]
+		s.warSideStrokeColors[side] = colors.Stroke
+		s.warSideFillColors[side] = colors.Fill
+	}
+
+	s.warFrontCasingStyle = NewStyle(
+		StyleOption{"stroke-width", "3.2pt"},
+		StyleOption{"stroke", c.PrimaryStroke},
+		StyleOption{"stroke-opacity", "0.8"},
+		StyleOption{"stroke-linecap", "round"},
+		StyleOption{"stroke-linejoin", "round"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.warFrontStyle = s.warFrontCasingStyle.With(
+		StyleOption{"stroke-width", "1.6pt"},
+		StyleOption{"stroke", c.WarFront},
+		StyleOption{"stroke-opacity", "1"},
+	)
+
+	s.warPocketStyle = s.warFrontStyle.With(
+		StyleOption{"stroke-dasharray", "2.0,1.5"},
+		StyleOption{"stroke-linecap", "butt"},
+	)
+
+	s.warOccupiedStyle = s.occupationCountryStyle.With(
+		StyleOption{"stroke-width", "1.2pt"},
+		StyleOption{"stroke-dasharray", "2.0,1.0"},
+	)
+
+	s.warPanelStyle = NewStyle(
+		StyleOption{"stroke-width", "0.33pt"},
+		StyleOption{"stroke", c.PrimaryFill},
+		StyleOption{"fill", c.Background},
+		StyleOption{"fill-opacity", "0.8"},
+	)
+
+	s.warSideTextStyle = s.starTextStyle.With(
+		StyleOption{"font-weight", "bold"},
+	)
+
+	s.warBarStyle = NewStyle(
+		StyleOption{"stroke-width", "0.2pt"},
+		StyleOption{"stroke", c.PrimaryFill},
+		StyleOption{"fill", "none"},
+	)
+
+	s.overlayCellStyle = NewStyle(
+		StyleOption{"stroke", "none"},
+	)
+
+	s.overlayLegendStyle = NewStyle(
+		StyleOption{"stroke-width", "0.33pt"},
+		StyleOption{"stroke", c.PrimaryFill},
+	)
+
+	s.distanceRingStyle = NewStyle(
+		StyleOption{"stroke-width", "0.8pt"},
+		StyleOption{"stroke", c.PrimaryFill},
+		StyleOption{"stroke-dasharray", "4.0,2.0"},
+		StyleOption{"stroke-opacity", "0.8"},
+		StyleOption{"fill", "none"},
+	)
+
+	s.fleetTextStyle = NewStyle(
 		StyleOption{"font-family", "sans-serif"},
-		StyleOption{"font-size", "3.2pt"},
+		StyleOption{"font-size", fmt.Sprintf("%gpt", sz.Font)},
 		StyleOption{"stroke-width", "0.08pt"},
-		StyleOption{"stroke", colorFleetFill},
-		StyleOption{"fill", colorFleetStroke},
+		StyleOption{"stroke", c.FleetFill},
+		StyleOption{"fill", c.FleetStroke},
 	)
-)
+
+	named := s.named()
+	for name, props := range theme.Styles {
+		style, ok := named[name]
+		if !ok {
+			log.Printf("warn: unknown style '%s' in theme %s", name, theme.Name)
+			continue
+		}
+		for prop, value := range props {
+			*style = style.With(StyleOption{prop, value})
+		}
+	}
+	return s
+}
+
+// named returns styles which can be overridden by themes. Overrides apply
+// only to the style itself and not to styles derived from it.
+func (s *styles) named() map[string]*Style {
+	return map[string]*Style{
+		"background":          &s.backgroundStyle,
+		"grid":                &s.gridStyle,
+		"hyperlane":           &s.hyperlaneStyle,
+		"default_star":        &s.defaultStarStyle,
+		"unknown_star":        &s.unknownStarStyle,
+		"outpost":             &s.outpostStyle,
+		"outpost_lost":        &s.outpostLostStyle,
+		"starbase":            &s.baseStarbaseStyle,
+		"starbase_lost":       &s.starbaseLostStyle,
+		"fleet_ident":         &s.fleetIdentStyle,
+		"planet":              &s.basePlanetStyle,
+		"planet_ring":         &s.planetRingStyle,
+		"star_text":           &s.starTextStyle,
+		"battle_text":         &s.battleTextStyle,
+		"country_text":        &s.countryTextStyle,
+		"country_legend":      &s.countryLegendStyle,
+		"player_text":         &s.playerTextStyle,
+		"country":             &s.baseCountryStyle,
+		"human_country":       &s.humanCountryStyle,
+		"occupation_country":  &s.occupationCountryStyle,
+		"occupation_pattern":  &s.occupationPatternStyle,
+		"monster_ring":        &s.monsterRingStyle,
+		"guarded_star":        &s.guardedStarStyle,
+		"crisis_country":      &s.crisisCountryStyle,
+		"crisis_pattern":      &s.crisisPatternStyle,
+		"bypass_link":         &s.bypassLinkStyle,
+		"bypass_text":         &s.bypassTextStyle,
+		"route":               &s.routeStyle,
+		"route_end":           &s.routeEndStyle,
+		"chokepoint":          &s.chokepointStyle,
+		"battle_attacker_won": &s.battleAttackerWonStyle,
+		"battle_defender_won": &s.battleDefenderWonStyle,
+		"war_front_casing":    &s.warFrontCasingStyle,
+		"war_front":           &s.warFrontStyle,
+		"war_pocket":          &s.warPocketStyle,
+		"war_occupied":        &s.warOccupiedStyle,
+		"war_panel":           &s.warPanelStyle,
+		"war_side_text":       &s.warSideTextStyle,
+		"war_bar":             &s.warBarStyle,
+		"overlay_cell":        &s.overlayCellStyle,
+		"overlay_legend":      &s.overlayLegendStyle,
+		"distance_ring":       &s.distanceRingStyle,
+		"fleet_text":          &s.fleetTextStyle,
+	}
+}
+
+// StyleNames returns names of styles which can be changed by themes
+func StyleNames() []string {
+	named := (&styles{}).named()
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
 type Style struct {
 	m map[string]string
@@ -216,3 +507,8 @@ func (s Style) String() string {
 
 	return s.s
 }
+
+// Get returns value of the property or empty string if it is not set
+func (s Style) Get(prop string) string {
+	return s.m[prop]
+}
@@ -0,0 +1,218 @@
+package sgmrender
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/beevik/etree"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const inkscapeNamespace = "http://www.inkscape.org/namespaces/inkscape"
+
+// layersScript adds controls which toggle layers when SVG file is opened
+// in a browser
+//
+//go:embed layers.js
+var layersScript string
+
+type svgElement struct {
+	el *etree.Element
+}
+
+func (e svgElement) SetTitle(title string) {
+	titleEl := e.el.CreateElement("title")
+	titleEl.CreateText(title)
+}
+
+func (e svgElement) SetId(id string) {
+	e.el.CreateAttr("id", id)
+}
+
+func (e svgElement) SetData(name, value string) {
+	e.el.CreateAttr("data-"+name, value)
+}
+
+func (e svgElement) Animate(animation Animation) {
+	animateEl := e.el.CreateElement("animate")
+	animateEl.CreateAttr("attributeName", animation.Property)
+	animateEl.CreateAttr("from", animation.From)
+	animateEl.CreateAttr("to", animation.To)
+	animateEl.CreateAttr("dur", animation.Duration)
+	animateEl.CreateAttr("repeatCount", "indefinite")
+}
+
+type svgGroup struct {
+	svgElement
+	canvas *SVGCanvas
+}
+
+func (g svgGroup) newGroup(el *etree.Element) svgGroup {
+	return svgGroup{svgElement: svgElement{el}, canvas: g.canvas}
+}
+
+func (g svgGroup) CreateGroup(style Style) Group {
+	groupEl := g.el.CreateElement("g")
+	if styleStr := style.String(); styleStr != "" {
+		groupEl.CreateAttr("style", styleStr)
+	}
+	return g.newGroup(groupEl)
+}
+
+func (g svgGroup) CreateTranslatedGroup(point sgmmath.Point) Group {
+	groupEl := g.el.CreateElement("g")
+	groupEl.CreateAttr("transform", fmt.Sprintf("translate(%f, %f)", point.X, point.Y))
+	return g.newGroup(groupEl)
+}
+
+func (g svgGroup) CreatePath(style Style, path Path) Element {
+	pathEl := g.el.CreateElement("path")
+	pathEl.CreateAttr("style", style.String())
+	pathEl.CreateAttr("d", path.String())
+	return svgElement{pathEl}
+}
+
+func (g svgGroup) CreateRect(style Style, rect sgmmath.BoundingRect) Element {
+	w, h := rect.Size()
+	rectEl := g.el.CreateElement("rect")
+	rectEl.CreateAttr("style", style.String())
+	rectEl.CreateAttr("x", fmt.Sprintf("%.4f", rect.Min.X))
+	rectEl.CreateAttr("y", fmt.Sprintf("%.4f", rect.Min.Y))
+	rectEl.CreateAttr("width", fmt.Sprintf("%.4f", w))
+	rectEl.CreateAttr("height", fmt.Sprintf("%.4f", h))
+	return svgElement{rectEl}
+}
+
+func (g svgGroup) CreateCircle(style Style, center sgmmath.Point, radius float64) Element {
+	circleEl := g.el.CreateElement("circle")
+	circleEl.CreateAttr("cx", fmt.Sprintf("%.4f", center.X))
+	circleEl.CreateAttr("cy", fmt.Sprintf("%.4f", center.Y))
+	circleEl.CreateAttr("r", fmt.Sprintf("%.4f", radius))
+	circleEl.CreateAttr("style", style.String())
+	return svgElement{circleEl}
+}
+
+func (g svgGroup) CreateText(style Style, point sgmmath.Point, text string) Element {
+	textEl := g.el.CreateElement("text")
+	textEl.CreateAttr("x", fmt.Sprintf("%.4f", point.X))
+	textEl.CreateAttr("y", fmt.Sprintf("%.4f", point.Y))
+	textEl.CreateAttr("style", style.String())
+	textEl.CreateText(text)
+	return svgElement{textEl}
+}
+
+func (g svgGroup) CreateIcon(point sgmmath.Point, name string, size float64) Element {
+	groupEl := g.el.CreateElement("g")
+	groupEl.CreateAttr("transform",
+		fmt.Sprintf("translate(%f, %f) scale(%f)", point.X, point.Y, size/float64(svgIconSize)))
+
+	icon := g.canvas.getIcon(name)
+	groupEl.AddChild(icon.Root().Copy())
+	return svgElement{groupEl}
+}
+
+// SVGCanvas draws the map as SVG document
+type SVGCanvas struct {
+	svgGroup
+
+	doc    *etree.Document
+	defs   *etree.Element
+	script *etree.Element
+
+	layerControls bool
+
+	iconDir   string
+	iconCache map[string]*etree.Document
+}
+
+func NewSVGCanvas(bounds sgmmath.BoundingRect, width, height float64) Canvas {
+	c := &SVGCanvas{
+		doc:       etree.NewDocument(),
+		iconCache: make(map[string]*etree.Document),
+	}
+	c.doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	w, h := bounds.Size()
+	svg := c.doc.CreateElement("svg")
+	svg.CreateAttr("xmlns", "http://www.w3.org/2000/svg")
+	svg.CreateAttr("xmlns:inkscape", inkscapeNamespace)
+	svg.CreateAttr("width", fmt.Sprint(width))
+	svg.CreateAttr("height", fmt.Sprint(height))
+	svg.CreateAttr("viewBox",
+		fmt.Sprintf("%f %f %f %f",
+			bounds.Min.X, bounds.Min.Y, w, h))
+
+	c.defs = svg.CreateElement("defs")
+	c.svgGroup = svgGroup{svgElement: svgElement{svg}, canvas: c}
+	return c
+}
+
+// EnableLayerControls embeds script which shows layer toggles when SVG is
+// opened in a browser. Browsers don't run it if SVG is inserted into HTML.
+func (c *SVGCanvas) EnableLayerControls() {
+	c.layerControls = true
+}
+
+// CreateLayer creates group which is shown as a layer by Inkscape
+func (c *SVGCanvas) CreateLayer(id, label string) Group {
+	if c.layerControls && c.script == nil {
+		c.script = c.el.CreateElement("script")
+		c.script.CreateAttr("type", "application/ecmascript")
+		c.script.CreateCData(layersScript)
+	}
+
+	layerEl := c.el.CreateElement("g")
+	layerEl.CreateAttr("id", "layer-"+id)
+	layerEl.CreateAttr("inkscape:groupmode", "layer")
+	layerEl.CreateAttr("inkscape:label", label)
+	return c.newGroup(layerEl)
+}
+
+func (c *SVGCanvas) CreatePattern(id string, size float64) Group {
+	pattern := c.defs.CreateElement("pattern")
+	pattern.CreateAttr("id", id)
+	pattern.CreateAttr("x", "0")
+	pattern.CreateAttr("y", "0")
+	pattern.CreateAttr("width", fmt.Sprint(size))
+	pattern.CreateAttr("height", fmt.Sprint(size))
+	pattern.CreateAttr("patternUnits", "userSpaceOnUse")
+	return c.newGroup(pattern)
+}
+
+func (c *SVGCanvas) CreateLinearGradient(id string, colors []string) {
+	gradient := c.defs.CreateElement("linearGradient")
+	gradient.CreateAttr("id", id)
+	for i, color := range colors {
+		stopEl := gradient.CreateElement("stop")
+		offset := 0.0
+		if len(colors) > 1 {
+			offset = float64(i) / float64(len(colors)-1)
+		}
+		stopEl.CreateAttr("offset", fmt.Sprintf("%.2f", offset))
+		stopEl.CreateAttr("stop-color", color)
+	}
+}
+
+func (c *SVGCanvas) HasDefinition(id string) bool {
+	return c.defs.FindElement(fmt.Sprintf("[@id='%s']", id)) != nil
+}
+
+func (c *SVGCanvas) WriteTo(w io.Writer) (int64, error) {
+	return c.doc.WriteTo(w)
+}
+
+func (c *SVGCanvas) SetIconDir(dir string) {
+	c.iconDir = dir
+	c.iconCache = make(map[string]*etree.Document)
+}
+
+func (c *SVGCanvas) getIcon(iconFile string) *etree.Document {
+	icon, ok := c.iconCache[iconFile]
+	if !ok {
+		icon = loadIcon(c.iconDir, iconFile)
+		c.iconCache[iconFile] = icon
+	}
+	return icon
+}
@@ -0,0 +1,214 @@
+package sgmrender
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultThemeName = "dark"
+
+//go:embed themes/*.yaml
+var embeddedThemesFS embed.FS
+
+// themesFS contains built-in themes, tests replace it to add broken ones
+var themesFS fs.FS = embeddedThemesFS
+
+// Theme defines colors, font and icon sizes of the map. Theme files are
+// YAML or JSON documents. A theme extends one of the built-in themes, the
+// dark one by default, and overrides only some of its values.
+type Theme struct {
+	Name    string `yaml:"name"`
+	Extends string `yaml:"extends"`
+
+	Colors ThemeColors `yaml:"colors"`
+	Sizes  ThemeSizes  `yaml:"sizes"`
+
+	// Properties of named styles which replace ones derived from colors and
+	// sizes, see StyleNames()
+	Styles map[string]map[string]string `yaml:"styles"`
+}
+
+type ThemeColors struct {
+	Background string `yaml:"background"`
+	Grid       string `yaml:"grid"`
+
+	PrimaryStroke  string `yaml:"primary_stroke"`
+	PrimaryFill    string `yaml:"primary_fill"`
+	FleetStroke    string `yaml:"fleet_stroke"`
+	FleetFill      string `yaml:"fleet_fill"`
+	StarStroke     string `yaml:"star_stroke"`
+	StarFill       string `yaml:"star_fill"`
+	StarbaseStroke string `yaml:"starbase_stroke"`
+	StarbaseFill   string `yaml:"starbase_fill"`
+	HostileStroke  string `yaml:"hostile_stroke"`
+	HostileFill    string `yaml:"hostile_fill"`
+	FriendlyStroke string `yaml:"friendly_stroke"`
+	FriendlyFill   string `yaml:"friendly_fill"`
+	PlanetStroke   string `yaml:"planet_stroke"`
+	PlanetFill     string `yaml:"planet_fill"`
+
+	// Colors of countries which flag colors are unknown
+	CountryBorder string `yaml:"country_border"`
+	CountryFill   string `yaml:"country_fill"`
+
+	Route    string `yaml:"route"`
+	WarFront string `yaml:"war_front"`
+
+	// Keys are names of danger levels: low, moderate, high, extreme
+	MonsterDanger map[string]string `yaml:"monster_danger"`
+	// Keys are crisis types: prethoryn, contingency, unbidden, player
+	Crisis map[string]ThemeColorPair `yaml:"crisis"`
+	// Keys are bypass types: wormhole, gateway, lgate
+	BypassLinks map[string]string `yaml:"bypass_links"`
+	// Keys are sides of war: uninvolved, attacker, defender
+	WarSides map[string]ThemeColorPair `yaml:"war_sides"`
+}
+
+type ThemeColorPair struct {
+	Stroke string `yaml:"stroke"`
+	Fill   string `yaml:"fill"`
+}
+
+// ThemeSizes are in map units, font sizes are in points
+type ThemeSizes struct {
+	Font        float64 `yaml:"font"`
+	SmallFont   float64 `yaml:"small_font"`
+	CountryFont float64 `yaml:"country_font"`
+	// Distance between lines of star names, battle years and legends
+	LineHeight float64 `yaml:"line_height"`
+
+	IconSmall      float64 `yaml:"icon_small"`
+	IconSmallStep  float64 `yaml:"icon_small_step"`
+	IconMedium     float64 `yaml:"icon_medium"`
+	IconMediumStep float64 `yaml:"icon_medium_step"`
+
+	// Widths of starbase outline, keys are levels: starport, starhold,
+	// starfortress, citadel
+	StarbaseStrokes map[string]float64 `yaml:"starbase_strokes"`
+}
+
+// BuiltinThemeNames returns names of themes embedded into the renderer
+func BuiltinThemeNames() []string {
+	entries, _ := fs.ReadDir(themesFS, "themes")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func BuiltinTheme(name string) (*Theme, error) {
+	data, err := fs.ReadFile(themesFS, path.Join("themes", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown theme '%s', should be one of %s",
+			name, strings.Join(BuiltinThemeNames(), ", "))
+	}
+	return parseTheme(data, "", 0)
+}
+
+// LoadTheme reads theme from the YAML or JSON file
+func LoadTheme(themePath string) (*Theme, error) {
+	data, err := os.ReadFile(themePath)
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := parseTheme(data, DefaultThemeName, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error loading theme %s: %w", themePath, err)
+	}
+	return theme, nil
+}
+
+// parseTheme decodes theme over the theme it extends, so values which are
+// not set are inherited from it
+func parseTheme(data []byte, base string, depth int) (*Theme, error) {
+	header := struct {
+		Extends string `yaml:"extends"`
+	}{Extends: base}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	theme := &Theme{}
+	if header.Extends != "" {
+		if depth > 8 {
+			return nil, fmt.Errorf("too many themes extending each other")
+		}
+
+		baseData, err := fs.ReadFile(themesFS, path.Join("themes", header.Extends+".yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("unknown base theme '%s'", header.Extends)
+		}
+		theme, err = parseTheme(baseData, "", depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Decoder merges keys of maps, but replaces color pairs entirely
+	basePairs := []map[string]ThemeColorPair{
+		copyColorPairs(theme.Colors.Crisis),
+		copyColorPairs(theme.Colors.WarSides),
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(theme); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for i, pairs := range []map[string]ThemeColorPair{theme.Colors.Crisis, theme.Colors.WarSides} {
+		for key, pair := range pairs {
+			base := basePairs[i][key]
+			if pair.Stroke == "" {
+				pair.Stroke = base.Stroke
+			}
+			if pair.Fill == "" {
+				pair.Fill = base.Fill
+			}
+			pairs[key] = pair
+		}
+	}
+	return theme, nil
+}
+
+func copyColorPairs(pairs map[string]ThemeColorPair) map[string]ThemeColorPair {
+	pairsCopy := make(map[string]ThemeColorPair, len(pairs))
+	for key, pair := range pairs {
+		pairsCopy[key] = pair
+	}
+	return pairsCopy
+}
+
+// loadTheme returns theme chosen in render options or the default one
+func (r *Renderer) loadTheme() *Theme {
+	if r.opts.CustomTheme != nil {
+		return r.opts.CustomTheme
+	}
+
+	name := r.opts.Theme
+	if name == "" {
+		name = DefaultThemeName
+	}
+	theme, err := BuiltinTheme(name)
+	if err != nil {
+		log.Printf("warn: %s, using %s theme", err, DefaultThemeName)
+		theme, err = BuiltinTheme(DefaultThemeName)
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+	return theme
+}
@@ -0,0 +1,111 @@
+package sgmrender
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTheme(t *testing.T) {
+	dark, err := BuiltinTheme("dark")
+	if !assert.NoError(t, err) {
+		return
+	}
+	light, err := BuiltinTheme("light")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tc := range []struct {
+		name  string
+		data  string
+		err   string
+		check func(t *testing.T, theme *Theme)
+	}{
+		{
+			name: "empty",
+			data: "",
+			check: func(t *testing.T, theme *Theme) {
+				assert.Equal(t, dark, theme)
+			},
+		},
+		{
+			name: "unknown key",
+			data: "colors:\n  backgorund: \"#000000\"\n",
+			err:  "field backgorund not found",
+		},
+		{
+			name: "unknown base",
+			data: "extends: neon\n",
+			err:  "unknown base theme 'neon'",
+		},
+		{
+			name: "partial override",
+			data: `
+colors:
+  background: "#000000"
+  monster_danger:
+    low: "#ffff00"
+  crisis:
+    player: {stroke: "#000000", fill: "#ffffff"}
+sizes:
+  font: 20
+`,
+			check: func(t *testing.T, theme *Theme) {
+				assert.Equal(t, "#000000", theme.Colors.Background)
+				assert.Equal(t, dark.Colors.Grid, theme.Colors.Grid)
+				assert.Equal(t, 20.0, theme.Sizes.Font)
+				assert.Equal(t, dark.Sizes.SmallFont, theme.Sizes.SmallFont)
+
+				assert.Equal(t, "#ffff00", theme.Colors.MonsterDanger["low"])
+				assert.Equal(t, dark.Colors.MonsterDanger["extreme"], theme.Colors.MonsterDanger["extreme"])
+				assert.Equal(t, ThemeColorPair{"#000000", "#ffffff"}, theme.Colors.Crisis["player"])
+				assert.Equal(t, dark.Colors.Crisis["prethoryn"], theme.Colors.Crisis["prethoryn"])
+			},
+		},
+		{
+			name: "partial color pair",
+			data: "colors:\n  crisis:\n    player: {fill: \"#ffffff\"}\n",
+			check: func(t *testing.T, theme *Theme) {
+				assert.Equal(t, ThemeColorPair{dark.Colors.Crisis["player"].Stroke, "#ffffff"},
+					theme.Colors.Crisis["player"])
+			},
+		},
+		{
+			name: "extends light",
+			data: "extends: light\ncolors:\n  war_sides:\n    attacker: {fill: \"#ffffff\"}\n",
+			check: func(t *testing.T, theme *Theme) {
+				assert.Equal(t, light.Colors.Background, theme.Colors.Background)
+				assert.Equal(t, light.Colors.WarSides["defender"], theme.Colors.WarSides["defender"])
+				assert.Equal(t, ThemeColorPair{light.Colors.WarSides["attacker"].Stroke, "#ffffff"},
+					theme.Colors.WarSides["attacker"])
+				assert.Equal(t, dark.Colors.MonsterDanger, theme.Colors.MonsterDanger)
+			},
+		},
+	} {
+		theme, err := parseTheme([]byte(tc.data), DefaultThemeName, 0)
+		if tc.err != "" {
+			if assert.Error(t, err, tc.name) {
+				assert.Contains(t, err.Error(), tc.err, tc.name)
+			}
+			continue
+		}
+		if assert.NoError(t, err, tc.name) {
+			tc.check(t, theme)
+		}
+	}
+}
+
+func TestParseThemeExtendsLoop(t *testing.T) {
+	defer func() { themesFS = embeddedThemesFS }()
+	themesFS = fstest.MapFS{
+		"themes/ping.yaml": {Data: []byte("extends: pong\n")},
+		"themes/pong.yaml": {Data: []byte("extends: ping\n")},
+	}
+
+	_, err := BuiltinTheme("ping")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "too many themes extending each other")
+	}
+}
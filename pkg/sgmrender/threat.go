@@ -0,0 +1,28 @@
+package sgmrender
+
+import (
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmgraph"
+)
+
+func (r *Renderer) newThreatOverlay() *MetricOverlay {
+	graph := sgmgraph.NewGraph(r.state, sgmgraph.Options{})
+	threats := graph.ComputeThreats(r.opts.ThreatCountry, sgmgraph.ThreatOptions{
+		MaxJumps:         r.opts.ThreatJumps,
+		WeightByDistance: r.opts.ThreatWeighted,
+		IsObserved:       r.isObserved,
+	})
+
+	power := make(map[*sgm.Star]float64)
+	for _, threat := range threats {
+		power[threat.Star] = threat.Power
+	}
+	return &MetricOverlay{
+		Title: "Threat",
+		Ramp:  RampHeat,
+		Metric: func(star *sgm.Star) (float64, bool) {
+			value, ok := power[star]
+			return value, ok
+		},
+	}
+}
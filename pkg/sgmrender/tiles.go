@@ -0,0 +1,262 @@
+package sgmrender
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	defaultTileSize  = 256
+	tileManifestName = "manifest.json"
+	tileURLTemplate  = "{z}/{x}/{y}.png"
+)
+
+type TileOptions struct {
+	// Size of square tiles in pixels, 256 by default
+	TileSize int
+
+	// Zoom levels where details appear and the maximum zoom level. Negative
+	// values are chosen from the size of the map, so stars appear at zoom
+	// which is close to the size of SVG image.
+	StarsZoom   int
+	SystemsZoom int
+	MaxZoom     int
+}
+
+// TileManifest describes the tile pyramid for web viewers. At zoom level
+// zero the whole map fits in a single tile, each next level doubles size
+// of the map.
+type TileManifest struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Date     string `json:"date"`
+	Footer   string `json:"footer"`
+
+	TileSize int    `json:"tileSize"`
+	TileURL  string `json:"tileUrl"`
+	MinZoom  int    `json:"minZoom"`
+	MaxZoom  int    `json:"maxZoom"`
+
+	// Size of the map in pixels at zoom level zero
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+
+	Background string            `json:"background"`
+	Levels     []TileDetailLevel `json:"levels"`
+}
+
+type TileDetailLevel struct {
+	Detail  string `json:"detail"`
+	MinZoom int    `json:"minZoom"`
+}
+
+// TileCanvas renders the map as z/x/y pyramid of PNG tiles showing more
+// details at higher zoom levels. It is written as a directory or as a zip
+// archive of the same layout.
+type TileCanvas struct {
+	*Scene
+
+	opts   TileOptions
+	legend Legend
+}
+
+func NewTileCanvasFactory(opts TileOptions) CanvasFactory {
+	return func(bounds sgmmath.BoundingRect, width, height float64) Canvas {
+		return &TileCanvas{
+			Scene: NewScene(bounds, width, height),
+			opts:  opts,
+		}
+	}
+}
+
+func (c *TileCanvas) SetLegend(legend Legend) {
+	c.legend = legend
+}
+
+// extent returns side of the square covering the map in scene units
+func (c *TileCanvas) extent() float64 {
+	w, h := c.Bounds.Size()
+	return math.Max(w, h)
+}
+
+func (c *TileCanvas) manifest() TileManifest {
+	opts := c.opts
+	if opts.TileSize <= 0 {
+		opts.TileSize = defaultTileSize
+	}
+
+	// Zoom level where the map has roughly the same size as SVG image
+	w, _ := c.Bounds.Size()
+	nativeZoom := int(math.Round(math.Log2(c.Width / w * c.extent() / float64(opts.TileSize))))
+	if nativeZoom < 1 {
+		nativeZoom = 1
+	}
+	if opts.StarsZoom < 0 {
+		opts.StarsZoom = nativeZoom
+	}
+	if opts.SystemsZoom < 0 {
+		opts.SystemsZoom = opts.StarsZoom + 1
+	}
+	if opts.MaxZoom < 0 {
+		opts.MaxZoom = opts.SystemsZoom + 1
+	}
+
+	scale := float64(opts.TileSize) / c.extent()
+	mapW, mapH := c.Bounds.Size()
+	return TileManifest{
+		Title:      c.legend.Title,
+		Subtitle:   c.legend.Subtitle,
+		Date:       c.legend.Date,
+		Footer:     c.legend.Footer,
+		TileSize:   opts.TileSize,
+		TileURL:    tileURLTemplate,
+		MaxZoom:    opts.MaxZoom,
+		Width:      mapW * scale,
+		Height:     mapH * scale,
+		Background: c.legend.Background,
+		Levels: []TileDetailLevel{
+			{Detail: DetailCountries.String()},
+			{Detail: DetailStars.String(), MinZoom: opts.StarsZoom},
+			{Detail: DetailSystems.String(), MinZoom: opts.SystemsZoom},
+		},
+	}
+}
+
+// detailAtZoom returns the most detailed level shown at zoom
+func (m *TileManifest) detailAtZoom(zoom int) DetailLevel {
+	detail := DetailCountries
+	for i, level := range m.Levels {
+		if zoom >= level.MinZoom {
+			detail = DetailLevel(i)
+		}
+	}
+	return detail
+}
+
+type tileJob struct {
+	zoom, x, y int
+	data       []byte
+	err        error
+}
+
+// renderTiles rasterizes tiles in parallel and passes encoded images to
+// the callback in a single goroutine
+func (c *TileCanvas) renderTiles(manifest *TileManifest, writeTile func(path string, data []byte) error) error {
+	var jobs []*tileJob
+	for zoom := manifest.MinZoom; zoom <= manifest.MaxZoom; zoom++ {
+		count := float64(int(1) << uint(zoom))
+		columns := int(math.Ceil(manifest.Width * count / float64(manifest.TileSize)))
+		rows := int(math.Ceil(manifest.Height * count / float64(manifest.TileSize)))
+		for x := 0; x < columns; x++ {
+			for y := 0; y < rows; y++ {
+				jobs = append(jobs, &tileJob{zoom: zoom, x: x, y: y})
+			}
+		}
+	}
+
+	queue := make(chan *tileJob)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.data, job.err = c.renderTile(manifest, job.zoom, job.x, job.y)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, job := range jobs {
+		if job.err != nil {
+			return job.err
+		}
+		err := writeTile(fmt.Sprintf("%d/%d/%d.png", job.zoom, job.x, job.y), job.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *TileCanvas) renderTile(manifest *TileManifest, zoom, x, y int) ([]byte, error) {
+	tileExtent := c.extent() / float64(int(1)<<uint(zoom))
+	min := c.Bounds.Min.Add(sgmmath.Point{X: float64(x) * tileExtent, Y: float64(y) * tileExtent})
+	viewport := sgmmath.BoundingRect{
+		Min: min,
+		Max: min.Add(sgmmath.Point{X: tileExtent, Y: tileExtent}),
+	}
+
+	img := c.RasterizeDetail(viewport, manifest.TileSize, manifest.TileSize,
+		manifest.detailAtZoom(zoom))
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// WriteDir writes manifest and tiles into the directory
+func (c *TileCanvas) WriteDir(dir string) error {
+	manifest := c.manifest()
+	err := c.renderTiles(&manifest, func(path string, data []byte) error {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, data, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, tileManifestName), data, 0644)
+}
+
+// WriteTo writes zip archive containing manifest and tiles
+func (c *TileCanvas) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	zw := zip.NewWriter(cw)
+
+	manifest := c.manifest()
+	writeFile := func(path string, data []byte) error {
+		// PNG images are already compressed
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	}
+	if err := c.renderTiles(&manifest, writeFile); err != nil {
+		return cw.n, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = writeFile(tileManifestName, data)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	return cw.n, err
+}
@@ -0,0 +1,102 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// newTestTileCanvas creates canvas of map 1000x500 units shown on the image
+// of 2048 pixels wide, so it needs 8 tiles of 256 pixels: zoom level 3
+func newTestTileCanvas(opts TileOptions) *TileCanvas {
+	bounds := sgmmath.BoundingRect{Max: sgmmath.Point{X: 1000, Y: 500}}
+	return NewTileCanvasFactory(opts)(bounds, 2048, 1024).(*TileCanvas)
+}
+
+func TestTileManifest(t *testing.T) {
+	tcs := []struct {
+		name   string
+		opts   TileOptions
+		tile   int
+		levels [3]int
+		max    int
+	}{
+		{
+			name:   "Auto",
+			opts:   TileOptions{StarsZoom: -1, SystemsZoom: -1, MaxZoom: -1},
+			tile:   256,
+			levels: [3]int{0, 3, 4},
+			max:    5,
+		},
+		{
+			name:   "AutoLargeTiles",
+			opts:   TileOptions{TileSize: 512, StarsZoom: -1, SystemsZoom: -1, MaxZoom: -1},
+			tile:   512,
+			levels: [3]int{0, 2, 3},
+			max:    4,
+		},
+		{
+			name:   "AutoSystems",
+			opts:   TileOptions{StarsZoom: 1, SystemsZoom: -1, MaxZoom: -1},
+			tile:   256,
+			levels: [3]int{0, 1, 2},
+			max:    3,
+		},
+		{
+			name:   "Explicit",
+			opts:   TileOptions{StarsZoom: 2, SystemsZoom: 5, MaxZoom: 6},
+			tile:   256,
+			levels: [3]int{0, 2, 5},
+			max:    6,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest := newTestTileCanvas(tc.opts).manifest()
+
+			assert.Equal(t, tc.tile, manifest.TileSize)
+			assert.Equal(t, 0, manifest.MinZoom)
+			assert.Equal(t, tc.max, manifest.MaxZoom)
+			if assert.Len(t, manifest.Levels, 3) {
+				for i, level := range manifest.Levels {
+					assert.Equal(t, DetailLevel(i).String(), level.Detail)
+					assert.Equal(t, tc.levels[i], level.MinZoom)
+				}
+			}
+
+			// Longer side of the map fits in a single tile at zoom level zero
+			assert.InDelta(t, float64(tc.tile), manifest.Width, 1e-9)
+			assert.InDelta(t, float64(tc.tile)/2, manifest.Height, 1e-9)
+		})
+	}
+}
+
+func TestTileManifestMinNativeZoom(t *testing.T) {
+	bounds := sgmmath.BoundingRect{Max: sgmmath.Point{X: 1000, Y: 1000}}
+	canvas := NewTileCanvasFactory(TileOptions{StarsZoom: -1, SystemsZoom: -1, MaxZoom: -1})(
+		bounds, 100, 100).(*TileCanvas)
+
+	manifest := canvas.manifest()
+	assert.Equal(t, 1, manifest.Levels[DetailStars].MinZoom)
+	assert.Equal(t, 3, manifest.MaxZoom)
+}
+
+func TestTileManifestDetailAtZoom(t *testing.T) {
+	manifest := newTestTileCanvas(TileOptions{StarsZoom: 2, SystemsZoom: 4, MaxZoom: 6}).manifest()
+
+	expected := []DetailLevel{
+		DetailCountries,
+		DetailCountries,
+		DetailStars,
+		DetailStars,
+		DetailSystems,
+		DetailSystems,
+		DetailSystems,
+	}
+	for zoom, detail := range expected {
+		assert.Equal(t, detail, manifest.detailAtZoom(zoom), "zoom %d", zoom)
+	}
+}
@@ -0,0 +1,115 @@
+package sgmrender
+
+import (
+	"log"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+// Distance from the edge of cropped map to legends in country font sizes
+const viewportLegendMargin = 1.0
+
+// computeBounds finds bounds of the whole galaxy and the area shown on the
+// map which is the galaxy too unless viewport is set in options. Legends are
+// drawn in corners of inner bounds: around the galaxy they're in the empty
+// space, but cropped map is filled by territories, so they're moved to the
+// edges of the image.
+func (r *Renderer) computeBounds() {
+	for _, star := range r.state.Stars {
+		r.galaxyBounds.Add(star.Point())
+	}
+
+	r.innerBounds = r.galaxyBounds
+	r.galaxyBounds.Expand(canvasPadding)
+	r.bounds = r.galaxyBounds
+
+	if viewport, ok := r.computeViewport(); ok {
+		padding := r.opts.ViewportPadding
+		if padding <= 0 {
+			padding = canvasPadding
+		}
+
+		r.bounds = viewport
+		r.bounds.Expand(padding)
+		r.innerBounds = r.bounds
+		r.innerBounds.Expand(-viewportLegendMargin * r.styles.countryFontSize)
+	}
+}
+
+// computeViewport returns area framed by viewport options, box in galaxy
+// coordinates takes precedence over countries, and countries over star
+func (r *Renderer) computeViewport() (sgmmath.BoundingRect, bool) {
+	viewport := sgmmath.NewBoundingRect()
+	switch {
+	case len(r.opts.Viewport) > 0:
+		if len(r.opts.Viewport) != 4 {
+			log.Printf("warn: viewport should have 4 coordinates, got %d, rendering whole galaxy",
+				len(r.opts.Viewport))
+			return viewport, false
+		}
+
+		// Map is mirrored relative to galaxy coordinates, see Star.Point()
+		viewport.Add(sgmmath.Point{X: -r.opts.Viewport[0], Y: r.opts.Viewport[1]})
+		viewport.Add(sgmmath.Point{X: -r.opts.Viewport[2], Y: r.opts.Viewport[3]})
+		if w, h := viewport.Size(); w == 0 || h == 0 {
+			log.Printf("warn: viewport %v is empty, rendering whole galaxy", r.opts.Viewport)
+			return viewport, false
+		}
+	case len(r.opts.ViewportCountries) > 0:
+		countries := make(map[sgm.CountryId]struct{})
+		for _, countryId := range r.opts.ViewportCountries {
+			countries[countryId] = struct{}{}
+		}
+		for _, star := range r.state.Stars {
+			if _, ok := countries[star.Owner()]; ok {
+				viewport.Add(star.Point())
+			}
+		}
+		if viewport.IsZero() {
+			log.Printf("warn: countries %v don't own any systems, rendering whole galaxy",
+				r.opts.ViewportCountries)
+			return viewport, false
+		}
+	case r.opts.ViewportRadius > 0:
+		star := r.state.Stars[r.opts.ViewportStar]
+		if star == nil {
+			log.Printf("warn: unknown viewport star %d, rendering whole galaxy", r.opts.ViewportStar)
+			return viewport, false
+		}
+
+		viewport = sgmmath.NewPointBoundingRect(star.Point())
+		viewport.Expand(r.opts.ViewportRadius)
+	default:
+		return viewport, false
+	}
+	return viewport, true
+}
+
+// canvasSize returns size of the map image, if both width and height are
+// set, bounds are extended to keep aspect ratio of the map
+func (r *Renderer) canvasSize() (float64, float64) {
+	w, h := r.bounds.Size()
+	width, height := r.opts.Width, r.opts.Height
+	switch {
+	case width > 0 && height > 0:
+		var d sgmmath.Point
+		if width/height > w/h {
+			d.X = (h*width/height - w) / 2
+		} else {
+			d.Y = (w*height/width - h) / 2
+		}
+
+		// Legends are drawn in corners of inner bounds, so they're moved too
+		for _, bounds := range []*sgmmath.BoundingRect{&r.bounds, &r.innerBounds} {
+			bounds.Min = bounds.Min.Sub(d)
+			bounds.Max = bounds.Max.Add(d)
+		}
+		return width, height
+	case width > 0:
+		return width, width * h / w
+	case height <= 0:
+		height = canvasSize
+	}
+	return w / h * height, height
+}
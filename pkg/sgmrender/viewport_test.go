@@ -0,0 +1,170 @@
+package sgmrender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+func newTestRect(minX, minY, maxX, maxY float64) sgmmath.BoundingRect {
+	return sgmmath.BoundingRect{
+		Min: sgmmath.Point{X: minX, Y: minY},
+		Max: sgmmath.Point{X: maxX, Y: maxY},
+	}
+}
+
+func TestComputeViewport(t *testing.T) {
+	tcs := []struct {
+		name     string
+		opts     RenderOptions
+		expected sgmmath.BoundingRect
+		ok       bool
+	}{
+		{
+			name: "Galaxy",
+			opts: RenderOptions{},
+		},
+		{
+			// Galaxy coordinates are mirrored, see Star.Point()
+			name:     "Box",
+			opts:     RenderOptions{Viewport: []float64{10, 20, 50, 80}},
+			expected: newTestRect(-50, 20, -10, 80),
+			ok:       true,
+		},
+		{
+			name: "BoxCoordinates",
+			opts: RenderOptions{Viewport: []float64{10, 20, 50}},
+		},
+		{
+			name: "BoxEmpty",
+			opts: RenderOptions{Viewport: []float64{10, 20, 10, 80}},
+		},
+		{
+			name:     "Countries",
+			opts:     RenderOptions{ViewportCountries: []sgm.CountryId{1}},
+			expected: newTestRect(-220, 30, -100, 110),
+			ok:       true,
+		},
+		{
+			name: "CountriesWithoutSystems",
+			opts: RenderOptions{ViewportCountries: []sgm.CountryId{2}},
+		},
+		{
+			name:     "Star",
+			opts:     RenderOptions{ViewportStar: 1, ViewportRadius: 20},
+			expected: newTestRect(-120, 10, -80, 50),
+			ok:       true,
+		},
+		{
+			name: "StarUnknown",
+			opts: RenderOptions{ViewportStar: 10, ViewportRadius: 20},
+		},
+		{
+			name: "BoxOverStar",
+			opts: RenderOptions{
+				Viewport:     []float64{10, 20, 50, 80},
+				ViewportStar: 1, ViewportRadius: 20,
+			},
+			expected: newTestRect(-50, 20, -10, 80),
+			ok:       true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newTestState()
+			for _, starId := range []sgm.StarId{1, 2} {
+				state.Stars[starId].Sector = &sgm.Sector{Owner: 1}
+			}
+
+			r := NewRenderer(state, tc.opts)
+			viewport, ok := r.computeViewport()
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, viewport)
+			}
+		})
+	}
+}
+
+func TestComputeBoundsViewport(t *testing.T) {
+	r := NewRenderer(newTestState(), RenderOptions{
+		Viewport:        []float64{10, 20, 50, 80},
+		ViewportPadding: 5,
+	})
+
+	assert.Equal(t, newTestRect(-55, 15, -5, 85), r.bounds)
+	assert.Equal(t, newTestRect(-220-canvasPadding, -canvasPadding, canvasPadding, 140+canvasPadding),
+		r.galaxyBounds)
+
+	// Legends are drawn near edges of the cropped map
+	margin := viewportLegendMargin * r.styles.countryFontSize
+	assert.Equal(t, newTestRect(-55+margin, 15+margin, -5-margin, 85-margin), r.innerBounds)
+}
+
+func TestCanvasSize(t *testing.T) {
+	tcs := []struct {
+		name          string
+		width, height float64
+
+		expectedWidth, expectedHeight float64
+		expectedBounds                sgmmath.BoundingRect
+	}{
+		{
+			name:           "Default",
+			expectedWidth:  2 * canvasSize,
+			expectedHeight: canvasSize,
+			expectedBounds: newTestRect(0, 0, 200, 100),
+		},
+		{
+			name:           "Width",
+			width:          400,
+			expectedWidth:  400,
+			expectedHeight: 200,
+			expectedBounds: newTestRect(0, 0, 200, 100),
+		},
+		{
+			name:           "Height",
+			height:         300,
+			expectedWidth:  600,
+			expectedHeight: 300,
+			expectedBounds: newTestRect(0, 0, 200, 100),
+		},
+		{
+			name:           "Taller",
+			width:          400,
+			height:         400,
+			expectedWidth:  400,
+			expectedHeight: 400,
+			expectedBounds: newTestRect(0, -50, 200, 150),
+		},
+		{
+			name:           "Wider",
+			width:          800,
+			height:         200,
+			expectedWidth:  800,
+			expectedHeight: 200,
+			expectedBounds: newTestRect(-100, 0, 300, 100),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Renderer{opts: RenderOptions{Width: tc.width, Height: tc.height}}
+			r.bounds = newTestRect(0, 0, 200, 100)
+			r.innerBounds = newTestRect(10, 10, 190, 90)
+
+			width, height := r.canvasSize()
+			assert.InDelta(t, tc.expectedWidth, width, 1e-9)
+			assert.InDelta(t, tc.expectedHeight, height, 1e-9)
+			assert.Equal(t, tc.expectedBounds, r.bounds)
+
+			// Inner bounds keep their distance to edges of the map
+			assert.Equal(t, r.bounds.Min.Add(sgmmath.Point{X: 10, Y: 10}), r.innerBounds.Min)
+			assert.Equal(t, r.bounds.Max.Sub(sgmmath.Point{X: 10, Y: 10}), r.innerBounds.Max)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package sgmrender
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgm"
+	"github.com/myaut/stellaris-galaxy-map/pkg/sgmmath"
+)
+
+const (
+	warPanelWidth   = 80.0
+	warPanelPadding = 2.0
+	warPanelLines   = 2.5
+	warBarHeight    = 2.0
+
+	// Layer controls are placed under the panel with this id, see layers.js
+	warSummaryId = "war-summary"
+)
+
+func (r *Renderer) findWar() *sgm.War {
+	if !r.opts.ShowWar {
+		return nil
+	}
+
+	war := r.state.Wars[r.opts.War]
+	if war == nil {
+		log.Printf("warn: war #%d is not found", r.opts.War)
+	}
+	return war
+}
+
+// renderWarFronts draws front lines between sides of the war as a thick
+// line over a dark casing, pockets are drawn dashed
+func (r *Renderer) renderWarFronts() {
+	if r.war == nil {
+		return
+	}
+
+	g := r.layer.CreateGroup(Style{})
+	for _, front := range r.FrontLines(r.war) {
+		path := front.path()
+		style, title := r.styles.warFrontStyle, "Front line"
+		if front.Pocket {
+			style, title = r.styles.warPocketStyle, "Pocket"
+		}
+
+		g.CreatePath(r.styles.warFrontCasingStyle, path)
+		pathEl := g.CreatePath(style, path)
+		pathEl.SetTitle(fmt.Sprintf("%s: %d attacker and %d defender systems",
+			title, len(front.AttackerStarIds), len(front.DefenderStarIds)))
+	}
+}
+
+type warSideSummary struct {
+	side      sgm.WarSide
+	title     string
+	countries []sgm.WarCountry
+	goal      sgm.WarGoal
+
+	exhaustion float64
+	score      float64
+
+	battlesWon int
+	occupied   int
+}
+
+func (r *Renderer) summarizeWar() []*warSideSummary {
+	attackers := &warSideSummary{
+		side:       sgm.WarSideAttacker,
+		title:      "Attackers",
+		countries:  r.war.Attackers,
+		goal:       r.war.AttackerWarGoal,
+		exhaustion: r.war.AttackerWarExhaustion,
+		score:      r.war.AttackerWarScore,
+	}
+	defenders := &warSideSummary{
+		side:       sgm.WarSideDefender,
+		title:      "Defenders",
+		countries:  r.war.Defenders,
+		goal:       r.war.DefenderWarGoal,
+		exhaustion: r.war.DefenderWarExhaustion,
+		score:      r.war.DefenderWarScore,
+	}
+
+	for _, battle := range r.war.Battles {
+		if battle.AttackerVictory {
+			attackers.battlesWon++
+		} else {
+			defenders.battlesWon++
+		}
+	}
+	for _, star := range r.state.Stars {
+		switch r.war.CountrySide(star.Occupier()) {
+		case sgm.WarSideAttacker:
+			attackers.occupied++
+		case sgm.WarSideDefender:
+			defenders.occupied++
+		}
+	}
+
+	return []*warSideSummary{attackers, defenders}
+}
+
+// renderWarSummary draws panel with goals and exhaustion of both sides in
+// the top right corner of the map
+func (r *Renderer) renderWarSummary() {
+	if r.war == nil {
+		return
+	}
+
+	summaries := r.summarizeWar()
+	lineCount := 2 + 6*len(summaries)
+	lineStep := warPanelLines * r.styles.fontSize
+	panelRect := sgmmath.BoundingRect{
+		Min: sgmmath.Point{X: r.innerBounds.Max.X - warPanelWidth, Y: r.innerBounds.Min.Y},
+		Max: sgmmath.Point{
+			X: r.innerBounds.Max.X,
+			Y: r.innerBounds.Min.Y + float64(lineCount)*lineStep + 2*warPanelPadding,
+		},
+	}
+
+	g := r.layer.CreateGroup(Style{})
+	g.SetId(warSummaryId)
+	g.CreateRect(r.styles.warPanelStyle, panelRect)
+
+	point := panelRect.Min.Add(sgmmath.Point{X: warPanelPadding, Y: warPanelPadding + lineStep})
+	attackerId, defenderId := r.war.Leaders()
+	g.CreateText(r.styles.countryLegendStyle, point, fmt.Sprintf("%s vs %s",
+		r.countryName(attackerId, r.state.Countries[attackerId]),
+		r.countryName(defenderId, r.state.Countries[defenderId])))
+	point.Y += lineStep
+	g.CreateText(r.styles.starTextStyle, point, fmt.Sprintf("Since %s", r.war.StartDate))
+
+	for _, summary := range summaries {
+		point.Y += lineStep
+		countryIds := make([]sgm.CountryId, 0, len(summary.countries))
+		for _, wc := range summary.countries {
+			countryIds = append(countryIds, wc.CountryId)
+		}
+		g.CreateText(r.styles.warSideTextStyle.With(
+			StyleOption{"fill", r.styles.warSideFillColors[summary.side]},
+		), point, summary.title+": "+r.countryNames(countryIds))
+
+		point.Y += lineStep
+		g.CreateText(r.styles.starTextStyle, point, "War goal: "+summary.goal.Name())
+
+		point.Y += lineStep
+		r.renderWarExhaustionBar(g, point, summary)
+
+		point.Y += lineStep
+		g.CreateText(r.styles.starTextStyle, point, fmt.Sprintf("Battles won: %d", summary.battlesWon))
+		point.Y += lineStep
+		g.CreateText(r.styles.starTextStyle, point, fmt.Sprintf("Systems occupied: %d", summary.occupied))
+		point.Y += lineStep
+		if summary.score != 0 {
+			g.CreateText(r.styles.starTextStyle, point, fmt.Sprintf("War score: %.f", summary.score))
+		}
+	}
+}
+
+func (r *Renderer) renderWarExhaustionBar(g Group, point sgmmath.Point, summary *warSideSummary) {
+	exhaustion := summary.exhaustion
+	if exhaustion > 1 {
+		exhaustion = 1
+	} else if exhaustion < 0 {
+		exhaustion = 0
+	}
+
+	barWidth := warPanelWidth/2 - warPanelPadding
+	barMin := point.Add(sgmmath.Point{X: warPanelWidth/2 - warPanelPadding, Y: -warBarHeight})
+	g.CreateRect(r.styles.warBarStyle, sgmmath.BoundingRect{
+		Min: barMin,
+		Max: barMin.Add(sgmmath.Point{X: barWidth, Y: warBarHeight}),
+	})
+	if exhaustion > 0 {
+		g.CreateRect(r.styles.warBarStyle.With(
+			StyleOption{"fill", r.styles.warSideFillColors[summary.side]},
+		), sgmmath.BoundingRect{
+			Min: barMin,
+			Max: barMin.Add(sgmmath.Point{X: barWidth * exhaustion, Y: warBarHeight}),
+		})
+	}
+
+	g.CreateText(r.styles.starTextStyle, point,
+		fmt.Sprintf("Exhaustion: %.f%%", 100*summary.exhaustion))
+}